@@ -0,0 +1,69 @@
+package compare
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zyy17/o11ybench/pkg/report"
+)
+
+// CompareOptions is the command options for `compare` command.
+type CompareOptions struct {
+	// ThroughputThreshold is the max drop of the throughputs in percent that is not a regression.
+	ThroughputThreshold float64
+
+	// LatencyThreshold is the max increase of the latency percentiles in percent that is not a regression.
+	LatencyThreshold float64
+}
+
+// NewCompareCmd creates the `compare` command that compares the saved JSON reports of the runs.
+func NewCompareCmd() *cobra.Command {
+	opts := &CompareOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "compare <baseline.json> <report.json>...",
+		Short: "Compare the reports of the runs with the baseline and detect the regressions",
+		Long: "Compare the JSON reports written by `--report` of the runs with the first one as the baseline. " +
+			"It exits with a non-zero code if any throughput drops or any latency percentile increases beyond the thresholds.",
+		Args: cobra.MinimumNArgs(2),
+
+		// The regressions are reported as the error, so the usage is not printed for them.
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return compare(opts, args)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.Float64Var(&opts.ThroughputThreshold, "throughput-threshold", 5, "The max drop of the throughputs in percent that is not a regression")
+	flags.Float64Var(&opts.LatencyThreshold, "latency-threshold", 10, "The max increase of the latency percentiles in percent that is not a regression")
+	return cmd
+}
+
+func compare(opts *CompareOptions, paths []string) error {
+	var reports []*report.Report
+	for _, path := range paths {
+		r, err := report.Load(path)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, r)
+	}
+
+	comparison, err := report.Compare(paths, reports, &report.Thresholds{Throughput: opts.ThroughputThreshold, Latency: opts.LatencyThreshold})
+	if err != nil {
+		return err
+	}
+
+	if err := comparison.Print(os.Stdout); err != nil {
+		return err
+	}
+
+	if regressions := comparison.Regressions(); regressions > 0 {
+		return fmt.Errorf("found '%d' regressions beyond the thresholds(throughput: %.2f%%, latency: %.2f%%)", regressions, opts.ThroughputThreshold, opts.LatencyThreshold)
+	}
+
+	return nil
+}
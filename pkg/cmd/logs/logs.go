@@ -4,7 +4,8 @@ import (
 	"github.com/spf13/cobra"
 
 	generatecmd "github.com/zyy17/o11ybench/pkg/cmd/logs/generate"
-	startcmd "github.com/zyy17/o11ybench/pkg/cmd/logs/start"
+	startcmd "github.com/zyy17/o11ybench/pkg/cmd/start"
+	"github.com/zyy17/o11ybench/pkg/generator"
 )
 
 func NewLogsCmd() *cobra.Command {
@@ -13,7 +14,7 @@ func NewLogsCmd() *cobra.Command {
 		Short: "Run the logs benchmark suites",
 	}
 
-	cmd.AddCommand(startcmd.NewStartCmd())
+	cmd.AddCommand(startcmd.NewStartCmd(generator.GeneratorTypeLogs))
 	cmd.AddCommand(generatecmd.NewGenerateCmd())
 
 	return cmd
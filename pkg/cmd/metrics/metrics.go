@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"github.com/spf13/cobra"
+
+	startcmd "github.com/zyy17/o11ybench/pkg/cmd/start"
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+func NewMetricsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "metrics <command>",
+		Short: "Run the metrics benchmark suites",
+	}
+
+	cmd.AddCommand(startcmd.NewStartCmd(generator.GeneratorTypeMetrics))
+
+	return cmd
+}
@@ -3,7 +3,9 @@ package root
 import (
 	"github.com/spf13/cobra"
 
+	"github.com/zyy17/o11ybench/pkg/cmd/compare"
 	"github.com/zyy17/o11ybench/pkg/cmd/logs"
+	"github.com/zyy17/o11ybench/pkg/cmd/metrics"
 )
 
 func NewRootCmd() *cobra.Command {
@@ -13,6 +15,8 @@ func NewRootCmd() *cobra.Command {
 	}
 
 	cmd.AddCommand(logs.NewLogsCmd())
+	cmd.AddCommand(metrics.NewMetricsCmd())
+	cmd.AddCommand(compare.NewCompareCmd())
 
 	return cmd
 }
@@ -0,0 +1,154 @@
+package start
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/spf13/cobra"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/config"
+	"github.com/zyy17/o11ybench/pkg/generator"
+	"github.com/zyy17/o11ybench/pkg/loader"
+	"github.com/zyy17/o11ybench/pkg/report"
+)
+
+// StartOptions is the command options for `start` subcommand.
+type StartOptions struct {
+	// ConfigFile is the configuration file path.
+	ConfigFile string
+
+	// MetricsAddr is the listen address of the Prometheus `/metrics` endpoint, for example, `:9099`. The endpoint is disabled if it's empty.
+	MetricsAddr string
+
+	// Reports are the paths of the result reports. The format of each report is decided by its extension, `.json` or `.csv`.
+	Reports []string
+
+	// ChartsDir is the directory to write the SVG charts of the run. The charts are not written if it's empty.
+	ChartsDir string
+}
+
+// NewStartCmd creates the `start` subcommand for the benchmark of the given generator type.
+func NewStartCmd(typ generator.GeneratorType) *cobra.Command {
+	opts := &StartOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "start",
+		Short: fmt.Sprintf("Start the %s benchmark", typ),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return start(opts, typ)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVarP(&opts.ConfigFile, "config", "c", "", "The path to the config file")
+	flags.StringVar(&opts.MetricsAddr, "metrics-addr", "", "The listen address to expose the live metrics of the benchmark in the Prometheus format, for example, ':9099'")
+	flags.StringSliceVar(&opts.Reports, "report", nil, "The path to write the result report, the format is JSON or CSV by the file extension, for example, 'result.json'. It can be repeated")
+	flags.StringVar(&opts.ChartsDir, "charts", "", "The directory to write the SVG charts of the throughput, latency and error rate of the run")
+	return cmd
+}
+
+func start(opts *StartOptions, typ generator.GeneratorType) error {
+	cfg, err := config.New(opts.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	if cfg.GeneratorConfig == nil {
+		return fmt.Errorf("generator config is required")
+	}
+
+	if typ == generator.GeneratorTypeLogs && cfg.GeneratorConfig.Logs == nil {
+		return fmt.Errorf("logs generator config is required")
+	}
+
+	if typ == generator.GeneratorTypeMetrics && cfg.GeneratorConfig.Metrics == nil {
+		return fmt.Errorf("metrics generator config is required")
+	}
+
+	if cfg.LoaderConfig == nil {
+		return fmt.Errorf("loader config is required")
+	}
+
+	if err := cfg.Print(); err != nil {
+		return err
+	}
+
+	// Setup the generator.
+	generator, err := generator.New(cfg.GeneratorConfig)
+	if err != nil {
+		return err
+	}
+
+	// Setup the collector.
+	collector := collector.New()
+
+	// Expose the live metrics, so the running benchmark can be graphed next to the metrics of the target.
+	if opts.MetricsAddr != "" {
+		server, err := serveMetrics(opts.MetricsAddr, collector)
+		if err != nil {
+			return err
+		}
+		defer server.Close()
+	}
+
+	// Setup the loader.
+	loader, err := loader.New(cfg.LoaderConfig, generator, collector)
+	if err != nil {
+		return err
+	}
+
+	// Start the loader.
+	if err := loader.Start(); err != nil {
+		return err
+	}
+
+	// Print the stats.
+	collector.Print()
+
+	// Write the reports and the charts.
+	if len(opts.Reports) > 0 || opts.ChartsDir != "" {
+		r, err := report.New(string(typ), cfg, collector)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range opts.Reports {
+			if err := r.Write(path); err != nil {
+				return err
+			}
+			fmt.Printf("The report is written to '%s'\n", path)
+		}
+
+		if opts.ChartsDir != "" {
+			if err := r.WriteCharts(opts.ChartsDir); err != nil {
+				return err
+			}
+			fmt.Printf("The charts are written to '%s'\n", opts.ChartsDir)
+		}
+	}
+
+	return nil
+}
+
+// serveMetrics starts the HTTP server that exposes the metrics of the collector on `/metrics`.
+func serveMetrics(addr string, c *collector.Collector) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on '%s' for the metrics: %w", addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", c.Handler())
+
+	server := &http.Server{Handler: mux}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			fmt.Printf("failed to serve the metrics: %v\n", err)
+		}
+	}()
+	fmt.Printf("Serving the metrics on 'http://%s/metrics'\n", listener.Addr())
+
+	return server, nil
+}
@@ -2,18 +2,97 @@ package collector
 
 import (
 	"fmt"
+	"math"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
+const (
+	// bytesPerMB is the number of the bytes in a MB.
+	bytesPerMB = 1000 * 1000
+
+	// secondsPerDay is the number of the seconds in a day.
+	secondsPerDay = 24 * 60 * 60
+)
+
 // Collector is used to collect the metrics during the load test.
 type Collector struct {
+	// name is the name of the stage. It's empty for the top level Collector.
+	name string
+
+	// parent is the top level Collector of the stage. The stats of the stage are also added to the parent.
+	parent *Collector
+
+	// stages are the Collectors of the stages of the load profile.
+	stages []*Collector
+
 	start    time.Time
 	stop     time.Time
 	success  atomic.Int64
 	failure  atomic.Int64
 	duration time.Duration
 	records  atomic.Int64
+	rejected atomic.Int64
+
+	// rawBytes is the size of the sent data before the compression.
+	rawBytes atomic.Int64
+
+	// wireBytes is the size of the sent data on the wire.
+	wireBytes atomic.Int64
+
+	// compressionTime is the total time spent on the compression of the requests, and compressions is the number of the compressed requests.
+	compressionTime atomic.Int64
+	compressions    atomic.Int64
+
+	// generateTime is the total time spent on generating the payloads of the requests, or taking them from the pool,
+	// and sendTime is the total time spent on sending the requests, including the encoding and the compression. Each attempt of the retries is a send.
+	generateTime atomic.Int64
+	generations  atomic.Int64
+	sendTime     atomic.Int64
+	sends        atomic.Int64
+
+	// poolSize is the number of the payloads in the pool, and poolPrepareTime is the time spent on preparing them before the run.
+	poolSize        int
+	poolPrepareTime time.Duration
+
+	// retries is the number of the retried attempts, retriedSuccess is the number of the requests that succeed after the retries,
+	// exhaustedRetries is the number of the requests that still fail after all the attempts,
+	// and abandonedRetries is the number of the failed requests whose retries are given up at the stop time or by the interrupt.
+	retries          atomic.Int64
+	retriedSuccess   atomic.Int64
+	exhaustedRetries atomic.Int64
+	abandonedRetries atomic.Int64
+
+	// newConnections and reusedConnections are the number of the requests sent by the new and the reused connections.
+	// The new connections of the long-running test are the connection churn, which costs the handshakes on both sides.
+	newConnections    atomic.Int64
+	reusedConnections atomic.Int64
+
+	// failures are the stats of the failures by the failure class.
+	failures   map[string]*Failure
+	failuresMu sync.Mutex
+
+	// latency is the latency of the requests measured from the intended start time.
+	latency Histogram
+
+	// sendLag is the lag between the intended start time and the actual start time of the requests.
+	sendLag Histogram
+
+	// inFlight is the number of the requests that are being sent.
+	inFlight atomic.Int64
+
+	// targetRate is the current target rate in the bits of float64, which changes over time with the load profile.
+	targetRate atomic.Uint64
+
+	// sampleInterval is the interval to sample the stats into the time series.
+	sampleInterval time.Duration
+
+	// sampler samples the stats into the time series while the top level Collector is running.
+	sampler *sampler
+
+	// intervalLatency is the latency of the requests in the current sample interval.
+	intervalLatency atomic.Pointer[Histogram]
 }
 
 // New creates a new Collector.
@@ -21,30 +100,302 @@ func New() *Collector {
 	return &Collector{}
 }
 
+// AddStage adds a stage of the load profile and returns the Collector of the stage.
+// The stats recorded by the Collector of the stage are also added to the top level Collector.
+// The stage is started and stopped when the load profile moves into and out of it, so its duration is the measured one.
+func (c *Collector) AddStage(name string) *Collector {
+	stage := &Collector{name: name, parent: c}
+	c.stages = append(c.stages, stage)
+
+	return stage
+}
+
 // Start starts the Collector.
 func (c *Collector) Start() {
 	c.start = time.Now()
+	if c.parent == nil {
+		c.startSampler()
+	}
 }
 
 // Stop stops the Collector.
 func (c *Collector) Stop() {
 	c.stop = time.Now()
 	c.duration = c.stop.Sub(c.start)
+	c.stopSampler()
 }
 
 // IncSuccessCount increments the success counter.
 func (c *Collector) IncSuccessCount(inc int64) {
 	c.success.Add(inc)
+	if c.parent != nil {
+		c.parent.IncSuccessCount(inc)
+	}
 }
 
 // IncRecordsCount increments the records counter.
 func (c *Collector) IncRecordsCount(inc int64) {
 	c.records.Add(inc)
+	if c.parent != nil {
+		c.parent.IncRecordsCount(inc)
+	}
+}
+
+// IncRejectedRecordsCount increments the counter of the records that are rejected by the target.
+func (c *Collector) IncRejectedRecordsCount(inc int64) {
+	c.rejected.Add(inc)
+	if c.parent != nil {
+		c.parent.IncRejectedRecordsCount(inc)
+	}
+}
+
+// IncBytes increments the counters of the sent bytes before the compression and on the wire.
+func (c *Collector) IncBytes(raw, wire int64) {
+	c.rawBytes.Add(raw)
+	c.wireBytes.Add(wire)
+	if c.parent != nil {
+		c.parent.IncBytes(raw, wire)
+	}
+}
+
+// ObserveCompressionTime records the time spent on the compression of a request.
+func (c *Collector) ObserveCompressionTime(d time.Duration) {
+	c.compressionTime.Add(int64(d))
+	c.compressions.Add(1)
+	if c.parent != nil {
+		c.parent.ObserveCompressionTime(d)
+	}
+}
+
+// CompressionTime returns the total time spent on the compression of the requests.
+func (c *Collector) CompressionTime() time.Duration {
+	return time.Duration(c.compressionTime.Load())
+}
+
+// MeanCompressionTime returns the mean time spent on the compression of a request.
+func (c *Collector) MeanCompressionTime() time.Duration {
+	count := c.compressions.Load()
+	if count == 0 {
+		return 0
+	}
+
+	return time.Duration(c.compressionTime.Load() / count)
+}
+
+// CompressionCores returns the average number of the CPU cores busy with the compression during the load test.
+func (c *Collector) CompressionCores() float64 {
+	return float64(c.compressionTime.Load()) / float64(c.duration)
+}
+
+// ObserveGenerateTime records the time spent on generating the payload of a request.
+func (c *Collector) ObserveGenerateTime(d time.Duration) {
+	c.generateTime.Add(int64(d))
+	c.generations.Add(1)
+	if c.parent != nil {
+		c.parent.ObserveGenerateTime(d)
+	}
+}
+
+// ObserveSendTime records the time spent on sending a request.
+func (c *Collector) ObserveSendTime(d time.Duration) {
+	c.sendTime.Add(int64(d))
+	c.sends.Add(1)
+	if c.parent != nil {
+		c.parent.ObserveSendTime(d)
+	}
+}
+
+// GenerateTime returns the total time spent on generating the payloads of the requests.
+func (c *Collector) GenerateTime() time.Duration {
+	return time.Duration(c.generateTime.Load())
+}
+
+// SendTime returns the total time spent on sending the requests.
+func (c *Collector) SendTime() time.Duration {
+	return time.Duration(c.sendTime.Load())
+}
+
+// SetPool sets the number of the payloads in the pool and the time spent on preparing them before the run.
+func (c *Collector) SetPool(size int, prepareTime time.Duration) {
+	c.poolSize = size
+	c.poolPrepareTime = prepareTime
+}
+
+// PoolSize returns the number of the payloads in the pool. It's 0 if the pool is not used.
+func (c *Collector) PoolSize() int {
+	return c.poolSize
+}
+
+// PoolPrepareTime returns the time spent on preparing the payloads of the pool before the run.
+func (c *Collector) PoolPrepareTime() time.Duration {
+	return c.poolPrepareTime
 }
 
 // IncFailureCount increments the failure counter.
 func (c *Collector) IncFailureCount(inc int64) {
 	c.failure.Add(inc)
+	if c.parent != nil {
+		c.parent.IncFailureCount(inc)
+	}
+}
+
+// IncRetriesCount increments the counter of the retried attempts.
+func (c *Collector) IncRetriesCount(inc int64) {
+	c.retries.Add(inc)
+	if c.parent != nil {
+		c.parent.IncRetriesCount(inc)
+	}
+}
+
+// IncRetriedSuccessCount increments the counter of the requests that succeed after the retries. They are also counted as the successful requests.
+func (c *Collector) IncRetriedSuccessCount(inc int64) {
+	c.retriedSuccess.Add(inc)
+	if c.parent != nil {
+		c.parent.IncRetriedSuccessCount(inc)
+	}
+}
+
+// IncExhaustedRetriesCount increments the counter of the requests that still fail after all the attempts. They are also counted as the failed requests.
+func (c *Collector) IncExhaustedRetriesCount(inc int64) {
+	c.exhaustedRetries.Add(inc)
+	if c.parent != nil {
+		c.parent.IncExhaustedRetriesCount(inc)
+	}
+}
+
+// IncAbandonedRetriesCount increments the counter of the requests whose retries are given up before all the attempts because the test is stopping.
+// They are also counted as the failed requests.
+func (c *Collector) IncAbandonedRetriesCount(inc int64) {
+	c.abandonedRetries.Add(inc)
+	if c.parent != nil {
+		c.parent.IncAbandonedRetriesCount(inc)
+	}
+}
+
+// IncConnections increments the counters of the new and the reused connections.
+func (c *Collector) IncConnections(newConns, reusedConns int64) {
+	c.newConnections.Add(newConns)
+	c.reusedConnections.Add(reusedConns)
+	if c.parent != nil {
+		c.parent.IncConnections(newConns, reusedConns)
+	}
+}
+
+// NewConnectionsCount returns the number of the new connections.
+func (c *Collector) NewConnectionsCount() int64 {
+	return c.newConnections.Load()
+}
+
+// ReusedConnectionsCount returns the number of the requests sent by the reused connections.
+func (c *Collector) ReusedConnectionsCount() int64 {
+	return c.reusedConnections.Load()
+}
+
+// IncInFlight increments the number of the requests that are being sent. It's decremented by a negative inc.
+func (c *Collector) IncInFlight(inc int64) {
+	c.inFlight.Add(inc)
+	if c.parent != nil {
+		c.parent.IncInFlight(inc)
+	}
+}
+
+// InFlight returns the number of the requests that are being sent.
+func (c *Collector) InFlight() int64 {
+	return c.inFlight.Load()
+}
+
+// SetTargetRate sets the current target rate of the requests.
+func (c *Collector) SetTargetRate(rate float64) {
+	c.targetRate.Store(math.Float64bits(rate))
+}
+
+// TargetRate returns the current target rate of the requests.
+func (c *Collector) TargetRate() float64 {
+	return math.Float64frombits(c.targetRate.Load())
+}
+
+// ObserveLatency records the latency of a request, which is measured from the intended start time of the request.
+func (c *Collector) ObserveLatency(d time.Duration) {
+	c.latency.Record(d)
+	if h := c.intervalLatency.Load(); h != nil {
+		h.Record(d)
+	}
+	if c.parent != nil {
+		c.parent.ObserveLatency(d)
+	}
+}
+
+// ObserveSendLag records the lag between the intended start time and the actual start time of a request.
+func (c *Collector) ObserveSendLag(d time.Duration) {
+	c.sendLag.Record(d)
+	if c.parent != nil {
+		c.parent.ObserveSendLag(d)
+	}
+}
+
+// Latency returns the histogram of the latency of the requests.
+func (c *Collector) Latency() *Histogram {
+	return &c.latency
+}
+
+// SendLag returns the histogram of the lag between the intended start time and the actual start time of the requests.
+func (c *Collector) SendLag() *Histogram {
+	return &c.sendLag
+}
+
+// MeanLatency returns the mean latency of the requests.
+func (c *Collector) MeanLatency() time.Duration {
+	return c.latency.Mean()
+}
+
+// MaxSendLag returns the max lag between the intended start time and the actual start time of the requests.
+func (c *Collector) MaxSendLag() time.Duration {
+	return c.sendLag.Max()
+}
+
+// SuccessCount returns the number of the successful requests.
+func (c *Collector) SuccessCount() int64 {
+	return c.success.Load()
+}
+
+// FailureCount returns the number of the failed requests.
+func (c *Collector) FailureCount() int64 {
+	return c.failure.Load()
+}
+
+// FirstAttemptSuccessCount returns the number of the requests that succeed at the first attempt.
+func (c *Collector) FirstAttemptSuccessCount() int64 {
+	return c.success.Load() - c.retriedSuccess.Load()
+}
+
+// RetriedSuccessCount returns the number of the requests that succeed after the retries.
+func (c *Collector) RetriedSuccessCount() int64 {
+	return c.retriedSuccess.Load()
+}
+
+// RetriesCount returns the number of the retried attempts.
+func (c *Collector) RetriesCount() int64 {
+	return c.retries.Load()
+}
+
+// ExhaustedRetriesCount returns the number of the requests that still fail after all the attempts.
+func (c *Collector) ExhaustedRetriesCount() int64 {
+	return c.exhaustedRetries.Load()
+}
+
+// AbandonedRetriesCount returns the number of the requests whose retries are given up because the test is stopping.
+func (c *Collector) AbandonedRetriesCount() int64 {
+	return c.abandonedRetries.Load()
+}
+
+// RecordsCount returns the number of the ingested records.
+func (c *Collector) RecordsCount() int64 {
+	return c.records.Load()
+}
+
+// RejectedRecordsCount returns the number of the records that are rejected by the target.
+func (c *Collector) RejectedRecordsCount() int64 {
+	return c.rejected.Load()
 }
 
 // Rate returns the actual rate of the load test.
@@ -57,6 +408,46 @@ func (c *Collector) RecordsRate() float64 {
 	return float64(c.records.Load()) / float64(c.duration.Seconds())
 }
 
+// RawBytes returns the size of the sent data before the compression.
+func (c *Collector) RawBytes() int64 {
+	return c.rawBytes.Load()
+}
+
+// WireBytes returns the size of the sent data on the wire.
+func (c *Collector) WireBytes() int64 {
+	return c.wireBytes.Load()
+}
+
+// RawThroughput returns the throughput of the data before the compression in MB/s.
+func (c *Collector) RawThroughput() float64 {
+	return float64(c.rawBytes.Load()) / bytesPerMB / c.duration.Seconds()
+}
+
+// WireThroughput returns the throughput of the data on the wire in MB/s.
+func (c *Collector) WireThroughput() float64 {
+	return float64(c.wireBytes.Load()) / bytesPerMB / c.duration.Seconds()
+}
+
+// CompressionRatio returns the ratio of the size before the compression to the size on the wire. It returns 0 if nothing is sent.
+func (c *Collector) CompressionRatio() float64 {
+	wire := c.wireBytes.Load()
+	if wire == 0 {
+		return 0
+	}
+
+	return float64(c.rawBytes.Load()) / float64(wire)
+}
+
+// StartTime returns the start time of the load test.
+func (c *Collector) StartTime() time.Time {
+	return c.start
+}
+
+// StopTime returns the stop time of the load test.
+func (c *Collector) StopTime() time.Time {
+	return c.stop
+}
+
 // Duration returns the duration of the load test.
 func (c *Collector) Duration() time.Duration {
 	return c.duration
@@ -66,4 +457,68 @@ func (c *Collector) Duration() time.Duration {
 func (c *Collector) Print() {
 	fmt.Printf("Success: \033[1m%d\033[0m, Failure: \033[1m%d\033[0m, Duration: \033[1m%s\033[0m, Rate: \033[1m%f\033[0m\n", c.success.Load(), c.failure.Load(), c.duration, c.Rate())
 	fmt.Printf("Ingested records: \033[1m%d\033[0m, records/s: \033[1m%f\033[0m\n", c.records.Load(), c.RecordsRate())
+	if c.wireBytes.Load() > 0 {
+		// The backends are usually compared by the ingested volume per day, so the throughput is also printed in GB/day.
+		fmt.Printf("Raw bytes: \033[1m%d\033[0m, MB/s: \033[1m%f\033[0m, GB/day: \033[1m%f\033[0m\n", c.rawBytes.Load(), c.RawThroughput(), c.RawThroughput()*secondsPerDay/1000)
+		fmt.Printf("Wire bytes: \033[1m%d\033[0m, MB/s: \033[1m%f\033[0m, Compression ratio: \033[1m%f\033[0m\n", c.wireBytes.Load(), c.WireThroughput(), c.CompressionRatio())
+	}
+	if c.poolSize > 0 {
+		fmt.Printf("Payload pool: \033[1m%d\033[0m payloads prepared in \033[1m%s\033[0m\n", c.poolSize, c.poolPrepareTime)
+	}
+	if c.sends.Load() > 0 {
+		// The generate time is separated from the send time, so it's clear whether the client or the target is the bottleneck.
+		fmt.Printf("Generate time: \033[1m%s\033[0m, mean: \033[1m%s\033[0m, Send time: \033[1m%s\033[0m, mean: \033[1m%s\033[0m\n",
+			c.GenerateTime(), meanDuration(c.generateTime.Load(), c.generations.Load()), c.SendTime(), meanDuration(c.sendTime.Load(), c.sends.Load()))
+	}
+	if c.compressions.Load() > 0 {
+		// The compression runs on the worker without blocking, so its wall time is the CPU time spent by the client.
+		fmt.Printf("Compression CPU time: \033[1m%s\033[0m, mean: \033[1m%s\033[0m, cores: \033[1m%f\033[0m\n", c.CompressionTime(), c.MeanCompressionTime(), c.CompressionCores())
+	}
+	if c.latency.Count() > 0 {
+		fmt.Printf("Latency: %s\n", histogramString(&c.latency))
+		fmt.Printf("Send lag: %s\n", histogramString(&c.sendLag))
+	}
+	if newConns := c.newConnections.Load(); newConns > 0 {
+		fmt.Printf("New connections: \033[1m%d\033[0m, Reused connections: \033[1m%d\033[0m, New connections/s: \033[1m%f\033[0m\n",
+			newConns, c.reusedConnections.Load(), float64(newConns)/c.duration.Seconds())
+	}
+	if retries, abandoned := c.retries.Load(), c.abandonedRetries.Load(); retries > 0 || abandoned > 0 {
+		fmt.Printf("First attempt success: \033[1m%d\033[0m, Success after retries: \033[1m%d\033[0m, Retries: \033[1m%d\033[0m, Exhausted retries: \033[1m%d\033[0m, Abandoned retries: \033[1m%d\033[0m\n",
+			c.FirstAttemptSuccessCount(), c.retriedSuccess.Load(), retries, c.exhaustedRetries.Load(), abandoned)
+	}
+	if rejected := c.rejected.Load(); rejected > 0 {
+		fmt.Printf("Rejected records: \033[1m%d\033[0m\n", rejected)
+	}
+
+	c.printFailures()
+
+	for _, stage := range c.stages {
+		fmt.Printf("Stage [%s]: Success: \033[1m%d\033[0m, Failure: \033[1m%d\033[0m, Duration: \033[1m%s\033[0m, Rate: \033[1m%f\033[0m, p99 latency: \033[1m%s\033[0m, Max send lag: \033[1m%s\033[0m\n",
+			stage.name, stage.success.Load(), stage.failure.Load(), stage.duration, stage.Rate(), stage.latency.Percentile(99), stage.MaxSendLag())
+	}
+}
+
+// Stages returns the Collectors of the stages of the load profile.
+func (c *Collector) Stages() []*Collector {
+	return c.stages
+}
+
+// Name returns the name of the stage.
+func (c *Collector) Name() string {
+	return c.name
+}
+
+// meanDuration returns the mean of the total duration in nanoseconds. It returns 0 if the count is 0.
+func meanDuration(total, count int64) time.Duration {
+	if count == 0 {
+		return 0
+	}
+
+	return time.Duration(total / count)
+}
+
+// histogramString formats the summary of the histogram.
+func histogramString(h *Histogram) string {
+	return fmt.Sprintf("min \033[1m%s\033[0m, mean \033[1m%s\033[0m, p50 \033[1m%s\033[0m, p90 \033[1m%s\033[0m, p99 \033[1m%s\033[0m, p99.9 \033[1m%s\033[0m, max \033[1m%s\033[0m",
+		h.Min(), h.Mean(), h.Percentile(50), h.Percentile(90), h.Percentile(99), h.Percentile(99.9), h.Max())
 }
@@ -40,3 +40,90 @@ func TestCollector(t *testing.T) {
 		t.Fatalf("actual duration: '%s', expected duration: '%s', delta: '%f'", collector.Duration(), duration, delta)
 	}
 }
+
+func TestCollectorStages(t *testing.T) {
+	collector := New()
+	warmup := collector.AddStage("warmup")
+	peak := collector.AddStage("peak")
+	peak.duration = 2 * time.Second
+
+	warmup.IncSuccessCount(10)
+	peak.IncSuccessCount(100)
+	peak.IncFailureCount(1)
+	peak.ObserveLatency(10 * time.Millisecond)
+	peak.ObserveLatency(30 * time.Millisecond)
+
+	if warmup.SuccessCount() != 10 || peak.SuccessCount() != 100 {
+		t.Fatalf("unexpected success of the stages: '%d', '%d'", warmup.SuccessCount(), peak.SuccessCount())
+	}
+
+	if collector.SuccessCount() != 110 {
+		t.Fatalf("the stats of the stages are not added to the collector: '%d'", collector.SuccessCount())
+	}
+
+	if peak.Rate() != 50 {
+		t.Fatalf("actual rate of the stage: '%f', expected rate: '50'", peak.Rate())
+	}
+
+	if collector.MeanLatency() != 20*time.Millisecond {
+		t.Fatalf("actual mean latency: '%s', expected mean latency: '20ms'", collector.MeanLatency())
+	}
+}
+
+func TestCollectorBytes(t *testing.T) {
+	collector := New()
+	stage := collector.AddStage("stage")
+	stage.duration = time.Second
+
+	collector.Start()
+	stage.IncBytes(3000000, 1000000)
+	collector.IncBytes(1000000, 1000000)
+	collector.Stop()
+
+	if collector.RawBytes() != 4000000 || collector.WireBytes() != 2000000 {
+		t.Fatalf("actual raw and wire bytes: '%d', '%d', expected: '4000000', '2000000'", collector.RawBytes(), collector.WireBytes())
+	}
+
+	if collector.CompressionRatio() != 2 || stage.CompressionRatio() != 3 {
+		t.Fatalf("actual compression ratio: '%f', '%f', expected: '2', '3'", collector.CompressionRatio(), stage.CompressionRatio())
+	}
+
+	if stage.RawThroughput() != 3 || stage.WireThroughput() != 1 {
+		t.Fatalf("actual throughput of the stage: '%f', '%f' MB/s, expected: '3', '1' MB/s", stage.RawThroughput(), stage.WireThroughput())
+	}
+}
+
+func TestCollectorIntervals(t *testing.T) {
+	collector := New()
+	collector.SetSampleInterval(100 * time.Millisecond)
+	collector.SetTargetRate(50)
+
+	collector.Start()
+	for i := 0; i < 3; i++ {
+		collector.IncSuccessCount(5)
+		collector.ObserveLatency(time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+	}
+	collector.Stop()
+
+	intervals := collector.Intervals()
+	if len(intervals) < 3 {
+		t.Fatalf("unexpected number of the intervals: '%d'", len(intervals))
+	}
+
+	var success, latencies int64
+	for _, interval := range intervals {
+		if interval.Duration < 10*time.Millisecond {
+			t.Fatalf("the short interval is not merged: '%s'", interval.Duration)
+		}
+		if interval.TargetRate != 50 {
+			t.Fatalf("unexpected target rate: '%f'", interval.TargetRate)
+		}
+		success += interval.Success
+		latencies += interval.LatencyCount
+	}
+
+	if success != 15 || latencies != 3 {
+		t.Fatalf("unexpected success: '%d', latencies: '%d'", success, latencies)
+	}
+}
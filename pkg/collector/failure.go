@@ -0,0 +1,179 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+const (
+	// FailureClassTimeout is the failure class of the timeouts, for example, dialing or waiting for the response.
+	FailureClassTimeout = "timeout"
+
+	// FailureClassConnectionRefused is the failure class of the refused connections.
+	FailureClassConnectionRefused = "connection refused"
+
+	// FailureClassConnectionReset is the failure class of the connections reset or broken by the target.
+	FailureClassConnectionReset = "connection reset"
+
+	// FailureClassConnectionClosed is the failure class of the connections closed by the target unexpectedly.
+	FailureClassConnectionClosed = "connection closed"
+
+	// FailureClassDNS is the failure class of the DNS resolution errors.
+	FailureClassDNS = "DNS"
+
+	// FailureClassOther is the failure class of the errors that can't be classified.
+	FailureClassOther = "other"
+)
+
+// maxFailureSamples is the max number of the distinct sample messages kept for each failure class.
+const maxFailureSamples = 3
+
+// maxFailureSampleSize is the max size of the sample messages, so the large response bodies are not kept in the stats and the report.
+const maxFailureSampleSize = 1024
+
+// FailureClassifier is implemented by the errors that know their failure class, for example, the errors of the unexpected HTTP status code.
+type FailureClassifier interface {
+	// FailureClass returns the failure class of the error, for example, `HTTP 503`.
+	FailureClass() string
+}
+
+// Failure is the stats of a failure class.
+type Failure struct {
+	// Class is the failure class.
+	Class string
+
+	// Count is the number of the failures of the class.
+	Count int64
+
+	// Samples are the distinct sample messages of the failures.
+	Samples []string
+}
+
+// ClassifyFailure returns the failure class of the error.
+func ClassifyFailure(err error) string {
+	var classifier FailureClassifier
+	if errors.As(err, &classifier) {
+		return classifier.FailureClass()
+	}
+
+	// The DNS errors may also be timeouts, so check them first.
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return FailureClassDNS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return FailureClassTimeout
+	}
+
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return FailureClassConnectionRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE):
+		return FailureClassConnectionReset
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return FailureClassConnectionClosed
+	}
+
+	return FailureClassOther
+}
+
+// RecordFailure increments the failure counter and records the error by its failure class.
+func (c *Collector) RecordFailure(err error) {
+	c.IncFailureCount(1)
+	c.recordFailure(ClassifyFailure(err), truncateMessage(err.Error(), maxFailureSampleSize))
+}
+
+// truncateMessage truncates the message to at most n bytes at the rune boundary and appends `...` if it's truncated.
+// The invalid UTF-8 bytes, for example, of a binary response body, are also replaced, so the message is always valid UTF-8.
+func truncateMessage(message string, n int) string {
+	message = strings.ToValidUTF8(message, string(utf8.RuneError))
+	if len(message) <= n {
+		return message
+	}
+
+	for n > 0 && !utf8.RuneStart(message[n]) {
+		n--
+	}
+
+	return message[:n] + "..."
+}
+
+func (c *Collector) recordFailure(class, message string) {
+	c.failuresMu.Lock()
+	if c.failures == nil {
+		c.failures = make(map[string]*Failure)
+	}
+
+	failure, ok := c.failures[class]
+	if !ok {
+		failure = &Failure{Class: class}
+		c.failures[class] = failure
+	}
+	failure.Count++
+
+	if len(failure.Samples) < maxFailureSamples && !slices.Contains(failure.Samples, message) {
+		failure.Samples = append(failure.Samples, message)
+	}
+	c.failuresMu.Unlock()
+
+	if c.parent != nil {
+		c.parent.recordFailure(class, message)
+	}
+}
+
+// Failures returns the stats of the failure classes in the descending order of the count.
+func (c *Collector) Failures() []*Failure {
+	c.failuresMu.Lock()
+	defer c.failuresMu.Unlock()
+
+	failures := make([]*Failure, 0, len(c.failures))
+	for _, failure := range c.failures {
+		failures = append(failures, &Failure{Class: failure.Class, Count: failure.Count, Samples: append([]string(nil), failure.Samples...)})
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		if failures[i].Count != failures[j].Count {
+			return failures[i].Count > failures[j].Count
+		}
+		return failures[i].Class < failures[j].Class
+	})
+
+	return failures
+}
+
+// printFailures prints the summary table of the failure classes.
+func (c *Collector) printFailures() {
+	failures := c.Failures()
+	if len(failures) == 0 {
+		return
+	}
+
+	fmt.Println("Failures:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  CLASS\tCOUNT\tSAMPLE")
+	for _, failure := range failures {
+		for i, sample := range failure.Samples {
+			// The long messages, for example, the response body, are truncated to keep the table readable.
+			sample = strings.ReplaceAll(truncateMessage(sample, 120), "\n", " ")
+
+			if i == 0 {
+				fmt.Fprintf(w, "  %s\t%d\t%s\n", failure.Class, failure.Count, sample)
+			} else {
+				fmt.Fprintf(w, "  \t\t%s\n", sample)
+			}
+		}
+	}
+	w.Flush()
+}
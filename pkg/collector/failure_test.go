@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"unicode/utf8"
+)
+
+type testClassifiedError struct{}
+
+func (e *testClassifiedError) Error() string        { return "service unavailable" }
+func (e *testClassifiedError) FailureClass() string { return "HTTP 503" }
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{err: fmt.Errorf("request failed: %w", &testClassifiedError{}), expected: "HTTP 503"},
+		{err: fmt.Errorf("request failed: %w", context.DeadlineExceeded), expected: FailureClassTimeout},
+		{err: &net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, expected: FailureClassTimeout},
+		{err: &net.OpError{Op: "dial", Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}}, expected: FailureClassConnectionRefused},
+		{err: &net.OpError{Op: "read", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}, expected: FailureClassConnectionReset},
+		{err: &net.OpError{Op: "write", Err: &os.SyscallError{Syscall: "write", Err: syscall.EPIPE}}, expected: FailureClassConnectionReset},
+		{err: &net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "unknown.invalid", IsTimeout: true}}, expected: FailureClassDNS},
+		{err: fmt.Errorf("failed to read the ack: %w", io.EOF), expected: FailureClassConnectionClosed},
+		{err: errors.New("unknown"), expected: FailureClassOther},
+	}
+
+	for i, tt := range tests {
+		if actual := ClassifyFailure(tt.err); actual != tt.expected {
+			t.Fatalf("Run test [%d]: actual class: '%s', expected class: '%s'", i, actual, tt.expected)
+		}
+	}
+}
+
+func TestCollectorFailures(t *testing.T) {
+	collector := New()
+	stage := collector.AddStage("stage")
+
+	for i := 0; i < 10; i++ {
+		stage.RecordFailure(fmt.Errorf("request %d failed: %w", i, &testClassifiedError{}))
+	}
+	collector.RecordFailure(context.DeadlineExceeded)
+
+	failures := collector.Failures()
+	if len(failures) != 2 {
+		t.Fatalf("unexpected failure classes: '%d'", len(failures))
+	}
+
+	// The failure classes are sorted by the count.
+	if failures[0].Class != "HTTP 503" || failures[0].Count != 10 || failures[1].Class != FailureClassTimeout || failures[1].Count != 1 {
+		t.Fatalf("unexpected failures: '%s: %d', '%s: %d'", failures[0].Class, failures[0].Count, failures[1].Class, failures[1].Count)
+	}
+
+	if len(failures[0].Samples) != maxFailureSamples || !strings.HasPrefix(failures[0].Samples[0], "request 0 failed") {
+		t.Fatalf("unexpected samples: '%v'", failures[0].Samples)
+	}
+
+	if len(stage.Failures()) != 1 || stage.Failures()[0].Count != 10 {
+		t.Fatalf("unexpected failures of the stage: '%v'", stage.Failures())
+	}
+
+	if collector.failure.Load() != 11 {
+		t.Fatalf("actual failure count: '%d', expected failure count: '11'", collector.failure.Load())
+	}
+}
+
+func TestTruncateMessage(t *testing.T) {
+	tests := []struct {
+		message  string
+		n        int
+		expected string
+	}{
+		{message: "short", n: 10, expected: "short"},
+		{message: "0123456789", n: 4, expected: "0123..."},
+		// The `世` is 3 bytes, so it's not split.
+		{message: "ab世界", n: 4, expected: "ab..."},
+		{message: "ab世界", n: 5, expected: "ab世..."},
+		{message: "bad \xff body", n: 100, expected: "bad � body"},
+	}
+
+	for _, tt := range tests {
+		actual := truncateMessage(tt.message, tt.n)
+		if actual != tt.expected || !utf8.ValidString(actual) {
+			t.Fatalf("unexpected truncated message of '%s' by %d: '%s', expected: '%s'", tt.message, tt.n, actual, tt.expected)
+		}
+	}
+}
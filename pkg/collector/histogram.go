@@ -0,0 +1,207 @@
+package collector
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// histogramSubBucketBits is the number of the bits of the sub-buckets in each power of two, which keeps the relative error under 1%.
+	histogramSubBucketBits = 7
+
+	// histogramSubBuckets is the number of the sub-buckets in each power of two.
+	histogramSubBuckets = 1 << histogramSubBucketBits
+
+	// histogramLinearBuckets is the number of the buckets for the small values which are recorded exactly.
+	histogramLinearBuckets = 2 * histogramSubBuckets
+
+	// histogramBuckets is the number of the buckets to cover all the positive int64 values.
+	histogramBuckets = histogramLinearBuckets + (64-histogramSubBucketBits-1)*histogramSubBuckets
+)
+
+// Histogram is an HDR-style histogram of the durations. The buckets are linear within each power of two, so the relative error is bounded.
+// It's safe to record the durations concurrently without the lock, and the histograms can be merged, for example, from the workers or the stages.
+type Histogram struct {
+	counts [histogramBuckets]atomic.Int64
+	count  atomic.Int64
+	sum    atomic.Int64
+	min    atomic.Int64
+	max    atomic.Int64
+}
+
+// NewHistogram creates a new Histogram.
+func NewHistogram() *Histogram {
+	return &Histogram{}
+}
+
+// Record records a duration. The negative duration is recorded as 0.
+func (h *Histogram) Record(d time.Duration) {
+	v := max(int64(d), 0)
+
+	h.counts[histogramIndex(v)].Add(1)
+	h.sum.Add(v)
+
+	// The min is stored as `v + 1` to tell it from the zero value when the histogram is empty.
+	for {
+		current := h.min.Load()
+		if (current != 0 && v+1 >= current) || h.min.CompareAndSwap(current, v+1) {
+			break
+		}
+	}
+
+	for {
+		current := h.max.Load()
+		if v <= current || h.max.CompareAndSwap(current, v) {
+			break
+		}
+	}
+
+	// The count is updated at last, so the other fields are ready when the count is observed.
+	h.count.Add(1)
+}
+
+// Merge adds the durations recorded by the other histogram.
+func (h *Histogram) Merge(other *Histogram) {
+	if other.Count() == 0 {
+		return
+	}
+
+	for i := range other.counts {
+		if n := other.counts[i].Load(); n > 0 {
+			h.counts[i].Add(n)
+		}
+	}
+	h.sum.Add(other.sum.Load())
+
+	for {
+		current, otherMin := h.min.Load(), other.min.Load()
+		if (current != 0 && otherMin >= current) || h.min.CompareAndSwap(current, otherMin) {
+			break
+		}
+	}
+
+	for {
+		current, otherMax := h.max.Load(), other.max.Load()
+		if otherMax <= current || h.max.CompareAndSwap(current, otherMax) {
+			break
+		}
+	}
+
+	h.count.Add(other.count.Load())
+}
+
+// Reset clears the recorded durations, so the histogram can be reused.
+func (h *Histogram) Reset() {
+	for i := range h.counts {
+		h.counts[i].Store(0)
+	}
+	h.sum.Store(0)
+	h.min.Store(0)
+	h.max.Store(0)
+	h.count.Store(0)
+}
+
+// Count returns the number of the recorded durations.
+func (h *Histogram) Count() int64 {
+	return h.count.Load()
+}
+
+// Min returns the min of the recorded durations.
+func (h *Histogram) Min() time.Duration {
+	if h.Count() == 0 {
+		return 0
+	}
+
+	return time.Duration(h.min.Load() - 1)
+}
+
+// Max returns the max of the recorded durations.
+func (h *Histogram) Max() time.Duration {
+	return time.Duration(h.max.Load())
+}
+
+// Mean returns the mean of the recorded durations.
+func (h *Histogram) Mean() time.Duration {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+
+	return time.Duration(h.sum.Load() / count)
+}
+
+// Sum returns the sum of the recorded durations.
+func (h *Histogram) Sum() time.Duration {
+	return time.Duration(h.sum.Load())
+}
+
+// CountAtOrBelow returns the number of the recorded durations that are less than or equal to d.
+// The bucket that d falls into is only counted if d is its upper bound, so the result may be slightly less than the exact count.
+func (h *Histogram) CountAtOrBelow(d time.Duration) int64 {
+	if d < 0 {
+		return 0
+	}
+
+	var cumulative int64
+	for i := range h.counts {
+		lower, width := histogramBucket(i)
+		if lower+width-1 > int64(d) {
+			break
+		}
+		cumulative += h.counts[i].Load()
+	}
+
+	return cumulative
+}
+
+// Percentile returns the duration at the given percentile, for example, `99.9`. The result is the middle of the bucket that the percentile falls into.
+func (h *Histogram) Percentile(percentile float64) time.Duration {
+	count := h.Count()
+	if count == 0 {
+		return 0
+	}
+
+	rank := max(int64(math.Ceil(percentile/100*float64(count))), 1)
+
+	var cumulative int64
+	for i := range h.counts {
+		cumulative += h.counts[i].Load()
+		if cumulative >= rank {
+			lower, width := histogramBucket(i)
+			v := lower + width/2
+
+			// The bucket is wider than the recorded range if there are only a few values, so clamp the result.
+			return min(max(time.Duration(v), h.Min()), h.Max())
+		}
+	}
+
+	return h.Max()
+}
+
+// histogramIndex returns the index of the bucket of the value.
+func histogramIndex(v int64) int {
+	if v < histogramLinearBuckets {
+		return int(v)
+	}
+
+	// The top bits of the value are in [histogramSubBuckets, 2*histogramSubBuckets).
+	shift := bits.Len64(uint64(v)) - histogramSubBucketBits - 1
+	top := int(v >> shift)
+
+	return histogramLinearBuckets + (shift-1)*histogramSubBuckets + top - histogramSubBuckets
+}
+
+// histogramBucket returns the lower bound and the width of the bucket.
+func histogramBucket(index int) (int64, int64) {
+	if index < histogramLinearBuckets {
+		return int64(index), 1
+	}
+
+	index -= histogramLinearBuckets
+	shift := index/histogramSubBuckets + 1
+	top := int64(index%histogramSubBuckets + histogramSubBuckets)
+
+	return top << shift, 1 << shift
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHistogramPercentile(t *testing.T) {
+	h := NewHistogram()
+
+	// Record 1µs, 2µs, ..., 100000µs in random order.
+	values := rand.Perm(100000)
+	for _, v := range values {
+		h.Record(time.Duration(v+1) * time.Microsecond)
+	}
+
+	if h.Count() != 100000 {
+		t.Fatalf("actual count: '%d', expected count: '100000'", h.Count())
+	}
+
+	if h.Min() != time.Microsecond || h.Max() != 100*time.Millisecond {
+		t.Fatalf("actual min and max: '%s', '%s', expected: '1µs', '100ms'", h.Min(), h.Max())
+	}
+
+	if h.Mean() != 50000500*time.Nanosecond {
+		t.Fatalf("actual mean: '%s', expected mean: '50.0005ms'", h.Mean())
+	}
+
+	tests := []struct {
+		percentile float64
+		expected   time.Duration
+	}{
+		{percentile: 50, expected: 50 * time.Millisecond},
+		{percentile: 90, expected: 90 * time.Millisecond},
+		{percentile: 99, expected: 99 * time.Millisecond},
+		{percentile: 99.9, expected: 99900 * time.Microsecond},
+		{percentile: 100, expected: 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		actual := h.Percentile(tt.percentile)
+
+		// The relative error of the histogram is less than 1%.
+		if math.Abs(float64(actual-tt.expected))/float64(tt.expected) > 0.01 {
+			t.Fatalf("actual p%v: '%s', expected p%v: '%s'", tt.percentile, actual, tt.percentile, tt.expected)
+		}
+	}
+}
+
+func TestHistogramSmallValues(t *testing.T) {
+	h := NewHistogram()
+	for _, v := range []time.Duration{3, 1, 2, -5} {
+		h.Record(v)
+	}
+
+	if h.Min() != 0 || h.Max() != 3 || h.Percentile(50) != 1 || h.Percentile(100) != 3 {
+		t.Fatalf("unexpected min, p50, p100 and max: '%d', '%d', '%d', '%d'", h.Min(), h.Percentile(50), h.Percentile(100), h.Max())
+	}
+
+	empty := NewHistogram()
+	if empty.Min() != 0 || empty.Max() != 0 || empty.Mean() != 0 || empty.Percentile(99) != 0 {
+		t.Fatalf("the empty histogram should return zero")
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	var (
+		wg      sync.WaitGroup
+		workers = make([]*Histogram, 4)
+		total   = NewHistogram()
+	)
+
+	// Each worker records into its own histogram and the shared histogram concurrently.
+	for i := range workers {
+		workers[i] = NewHistogram()
+		wg.Add(1)
+		go func(h *Histogram, offset int) {
+			defer wg.Done()
+			for v := 1; v <= 1000; v++ {
+				d := time.Duration(offset*1000+v) * time.Millisecond
+				h.Record(d)
+				total.Record(d)
+			}
+		}(workers[i], i)
+	}
+	wg.Wait()
+
+	merged := NewHistogram()
+	for _, h := range workers {
+		merged.Merge(h)
+	}
+
+	if merged.Count() != total.Count() || merged.Min() != total.Min() || merged.Max() != total.Max() || merged.Mean() != total.Mean() {
+		t.Fatalf("the merged histogram is different from the shared one: count '%d' vs '%d', min '%s' vs '%s', max '%s' vs '%s'",
+			merged.Count(), total.Count(), merged.Min(), total.Min(), merged.Max(), total.Max())
+	}
+
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		if merged.Percentile(p) != total.Percentile(p) {
+			t.Fatalf("actual p%v: '%s', expected p%v: '%s'", p, merged.Percentile(p), p, total.Percentile(p))
+		}
+	}
+}
+
+func TestHistogramReset(t *testing.T) {
+	h := NewHistogram()
+	h.Record(time.Second)
+	h.Reset()
+
+	if h.Count() != 0 || h.Min() != 0 || h.Max() != 0 || h.Sum() != 0 || h.Percentile(50) != 0 {
+		t.Fatalf("the histogram is not reset: count '%d', min '%s', max '%s'", h.Count(), h.Min(), h.Max())
+	}
+
+	h.Record(time.Millisecond)
+	if h.Count() != 1 || h.Min() != time.Millisecond || h.Percentile(50) != time.Millisecond {
+		t.Fatalf("unexpected histogram after reset: count '%d', min '%s', p50 '%s'", h.Count(), h.Min(), h.Percentile(50))
+	}
+}
@@ -0,0 +1,200 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// defaultSampleInterval is the default interval to sample the stats into the time series.
+const defaultSampleInterval = time.Second
+
+// Interval is the stats of the requests in a sample interval of the load test.
+type Interval struct {
+	// Start is the start time of the interval.
+	Start time.Time
+
+	// Duration is the duration of the interval. The last interval may be shorter than the sample interval.
+	Duration time.Duration
+
+	Success   int64
+	Failure   int64
+	Records   int64
+	RawBytes  int64
+	WireBytes int64
+
+	// TargetRate is the target rate at the end of the interval.
+	TargetRate float64
+
+	// LatencyCount, LatencyP50, LatencyP99 and LatencyMax are the latency of the requests finished in the interval.
+	// Only the percentiles are kept, so the memory doesn't grow with the histogram of each interval in the long runs.
+	LatencyCount int64
+	LatencyP50   time.Duration
+	LatencyP99   time.Duration
+	LatencyMax   time.Duration
+}
+
+// setLatency sets the latency of the interval by the histogram.
+func (i *Interval) setLatency(h *Histogram) {
+	i.LatencyCount = h.Count()
+	i.LatencyP50 = h.Percentile(50)
+	i.LatencyP99 = h.Percentile(99)
+	i.LatencyMax = h.Max()
+}
+
+// Rate returns the actual rate of the successful requests in the interval.
+func (i *Interval) Rate() float64 {
+	if i.Duration <= 0 {
+		return 0
+	}
+
+	return float64(i.Success) / i.Duration.Seconds()
+}
+
+// sampler samples the stats of the Collector into the intervals periodically.
+type sampler struct {
+	interval time.Duration
+	stop     chan struct{}
+	wg       sync.WaitGroup
+
+	mu        sync.Mutex
+	intervals []*Interval
+
+	// last is the snapshot of the counters at the start of the current interval.
+	last Interval
+
+	// prevLatency is the latency histogram of the last sampled interval. It's kept to merge the short last interval into it,
+	// and it's reset and reused for the next interval after that, so only a few histograms are allocated for the whole run.
+	prevLatency *Histogram
+
+	// spareLatency is the reset histogram to swap in for the next interval.
+	spareLatency *Histogram
+}
+
+// SetSampleInterval sets the interval to sample the stats into the time series. It should be called before the Collector starts.
+func (c *Collector) SetSampleInterval(interval time.Duration) {
+	c.sampleInterval = interval
+}
+
+// Intervals returns the stats of the sample intervals.
+func (c *Collector) Intervals() []*Interval {
+	if c.sampler == nil {
+		return nil
+	}
+
+	c.sampler.mu.Lock()
+	defer c.sampler.mu.Unlock()
+
+	return append([]*Interval(nil), c.sampler.intervals...)
+}
+
+func (c *Collector) startSampler() {
+	interval := c.sampleInterval
+	if interval <= 0 {
+		interval = defaultSampleInterval
+	}
+
+	s := &sampler{interval: interval, stop: make(chan struct{}), last: Interval{Start: c.start}, spareLatency: NewHistogram()}
+	c.intervalLatency.Store(NewHistogram())
+	c.sampler = s
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-s.stop:
+				return
+			case now := <-ticker.C:
+				c.sample(now)
+			}
+		}
+	}()
+}
+
+// stopSampler stops the sampling and samples the last interval at the stop time.
+func (c *Collector) stopSampler() {
+	s := c.sampler
+	if s == nil {
+		return
+	}
+
+	select {
+	case <-s.stop:
+		// The sampler is already stopped if the Collector is stopped more than once.
+		return
+	default:
+		close(s.stop)
+	}
+	s.wg.Wait()
+
+	// The last interval is merged into the previous one if it's too short, for example, the Collector is stopped right after a tick,
+	// otherwise its rate is meaningless.
+	s.mu.Lock()
+	short := len(s.intervals) > 0 && c.stop.Sub(s.last.Start) < s.interval/10
+	s.mu.Unlock()
+	if !short {
+		c.sample(c.stop)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev := s.intervals[len(s.intervals)-1]
+	prev.Duration += c.stop.Sub(s.last.Start)
+	prev.Success += c.success.Load() - s.last.Success
+	prev.Failure += c.failure.Load() - s.last.Failure
+	prev.Records += c.records.Load() - s.last.Records
+	prev.RawBytes += c.rawBytes.Load() - s.last.RawBytes
+	prev.WireBytes += c.wireBytes.Load() - s.last.WireBytes
+	prev.TargetRate = c.TargetRate()
+
+	// The histogram of the previous interval is kept until the next interval is sampled.
+	s.prevLatency.Merge(c.intervalLatency.Load())
+	prev.setLatency(s.prevLatency)
+}
+
+func (c *Collector) sample(now time.Time) {
+	s := c.sampler
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current := Interval{
+		Start:     now,
+		Success:   c.success.Load(),
+		Failure:   c.failure.Load(),
+		Records:   c.records.Load(),
+		RawBytes:  c.rawBytes.Load(),
+		WireBytes: c.wireBytes.Load(),
+	}
+
+	interval := &Interval{
+		Start:      s.last.Start,
+		Duration:   now.Sub(s.last.Start),
+		Success:    current.Success - s.last.Success,
+		Failure:    current.Failure - s.last.Failure,
+		Records:    current.Records - s.last.Records,
+		RawBytes:   current.RawBytes - s.last.RawBytes,
+		WireBytes:  current.WireBytes - s.last.WireBytes,
+		TargetRate: c.TargetRate(),
+	}
+
+	latency := c.intervalLatency.Swap(s.spareLatency)
+	interval.setLatency(latency)
+	s.intervals = append(s.intervals, interval)
+	s.last = current
+
+	// The histogram of the previous interval is no longer needed, so it's reused for the next interval.
+	if s.prevLatency != nil {
+		s.prevLatency.Reset()
+		s.spareLatency = s.prevLatency
+	} else {
+		s.spareLatency = NewHistogram()
+	}
+	s.prevLatency = latency
+}
@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// metricsPrefix is the prefix of the names of the exposed metrics.
+const metricsPrefix = "o11ybench_"
+
+// prometheusBuckets are the upper bounds of the buckets of the exposed histograms.
+var prometheusBuckets = []time.Duration{
+	time.Millisecond,
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// Handler returns the HTTP handler that exposes the live metrics of the Collector in the Prometheus text exposition format.
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if err := c.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// WritePrometheus writes the metrics of the Collector in the Prometheus text exposition format.
+// The stats of the stages are labeled by the stage name, so the stages of the load profile can be compared in the dashboard.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	writeMetricHeader(bw, "requests_total", "counter", "The number of the requests by the result.")
+	fmt.Fprintf(bw, "%srequests_total{result=\"success\"} %d\n", metricsPrefix, c.success.Load())
+	fmt.Fprintf(bw, "%srequests_total{result=\"failure\"} %d\n", metricsPrefix, c.failure.Load())
+
+	writeMetricHeader(bw, "retried_requests_total", "counter", "The number of the requests that are retried by the result, which is success after the retries, exhausted retries or retries abandoned because the test is stopping.")
+	fmt.Fprintf(bw, "%sretried_requests_total{result=\"success\"} %d\n", metricsPrefix, c.retriedSuccess.Load())
+	fmt.Fprintf(bw, "%sretried_requests_total{result=\"exhausted\"} %d\n", metricsPrefix, c.exhaustedRetries.Load())
+	fmt.Fprintf(bw, "%sretried_requests_total{result=\"abandoned\"} %d\n", metricsPrefix, c.abandonedRetries.Load())
+
+	writeMetricHeader(bw, "retries_total", "counter", "The number of the retried attempts.")
+	fmt.Fprintf(bw, "%sretries_total %d\n", metricsPrefix, c.retries.Load())
+
+	writeMetricHeader(bw, "records_total", "counter", "The number of the records accepted by the target.")
+	fmt.Fprintf(bw, "%srecords_total %d\n", metricsPrefix, c.records.Load())
+
+	writeMetricHeader(bw, "rejected_records_total", "counter", "The number of the records rejected by the target.")
+	fmt.Fprintf(bw, "%srejected_records_total %d\n", metricsPrefix, c.rejected.Load())
+
+	writeMetricHeader(bw, "sent_bytes_total", "counter", "The size of the sent data before the compression (raw) and on the wire (wire).")
+	fmt.Fprintf(bw, "%ssent_bytes_total{type=\"raw\"} %d\n", metricsPrefix, c.rawBytes.Load())
+	fmt.Fprintf(bw, "%ssent_bytes_total{type=\"wire\"} %d\n", metricsPrefix, c.wireBytes.Load())
+
+	writeMetricHeader(bw, "compression_cpu_seconds_total", "counter", "The CPU time spent on the compression of the requests.")
+	fmt.Fprintf(bw, "%scompression_cpu_seconds_total %s\n", metricsPrefix, formatFloat(c.CompressionTime().Seconds()))
+
+	writeMetricHeader(bw, "generate_seconds_total", "counter", "The time spent on generating the payloads of the requests.")
+	fmt.Fprintf(bw, "%sgenerate_seconds_total %s\n", metricsPrefix, formatFloat(c.GenerateTime().Seconds()))
+
+	writeMetricHeader(bw, "send_seconds_total", "counter", "The time spent on sending the requests, including the encoding and the compression.")
+	fmt.Fprintf(bw, "%ssend_seconds_total %s\n", metricsPrefix, formatFloat(c.SendTime().Seconds()))
+
+	writeMetricHeader(bw, "connections_total", "counter", "The number of the requests by whether the connection is new or reused.")
+	fmt.Fprintf(bw, "%sconnections_total{state=\"new\"} %d\n", metricsPrefix, c.newConnections.Load())
+	fmt.Fprintf(bw, "%sconnections_total{state=\"reused\"} %d\n", metricsPrefix, c.reusedConnections.Load())
+
+	writeMetricHeader(bw, "failures_total", "counter", "The number of the failed requests by the failure class.")
+	for _, failure := range c.Failures() {
+		fmt.Fprintf(bw, "%sfailures_total{class=\"%s\"} %d\n", metricsPrefix, escapeLabelValue(failure.Class), failure.Count)
+	}
+
+	writeMetricHeader(bw, "in_flight_requests", "gauge", "The number of the requests that are being sent.")
+	fmt.Fprintf(bw, "%sin_flight_requests %d\n", metricsPrefix, c.inFlight.Load())
+
+	writeMetricHeader(bw, "target_rate", "gauge", "The current target rate of the requests per second.")
+	fmt.Fprintf(bw, "%starget_rate %s\n", metricsPrefix, formatFloat(c.TargetRate()))
+
+	writeMetricHeader(bw, "request_latency_seconds", "histogram", "The latency of the requests measured from the intended start time.")
+	writeHistogram(bw, "request_latency_seconds", "", &c.latency)
+
+	writeMetricHeader(bw, "send_lag_seconds", "histogram", "The lag between the intended start time and the actual start time of the requests.")
+	writeHistogram(bw, "send_lag_seconds", "", &c.sendLag)
+
+	if len(c.stages) > 0 {
+		writeMetricHeader(bw, "stage_requests_total", "counter", "The number of the requests of the stages of the load profile by the result.")
+		for _, stage := range c.stages {
+			label := stageLabel(stage)
+			fmt.Fprintf(bw, "%sstage_requests_total{%s,result=\"success\"} %d\n", metricsPrefix, label, stage.success.Load())
+			fmt.Fprintf(bw, "%sstage_requests_total{%s,result=\"failure\"} %d\n", metricsPrefix, label, stage.failure.Load())
+		}
+
+		// The stage histograms are in their own family, so the requests are not counted twice by summing the overall histogram with them.
+		writeMetricHeader(bw, "stage_request_latency_seconds", "histogram", "The latency of the requests of the stages of the load profile measured from the intended start time.")
+		for _, stage := range c.stages {
+			writeHistogram(bw, "stage_request_latency_seconds", stageLabel(stage), &stage.latency)
+		}
+	}
+
+	return bw.Flush()
+}
+
+func writeMetricHeader(w io.Writer, name, typ, help string) {
+	fmt.Fprintf(w, "# HELP %s%s %s\n", metricsPrefix, name, help)
+	fmt.Fprintf(w, "# TYPE %s%s %s\n", metricsPrefix, name, typ)
+}
+
+// writeHistogram writes the histogram as the cumulative buckets. The labels are added to each sample if they are not empty.
+func writeHistogram(w io.Writer, name, labels string, h *Histogram) {
+	// The count is loaded first, so the buckets recorded concurrently don't exceed the `+Inf` bucket.
+	count := h.Count()
+
+	prefix := ""
+	if labels != "" {
+		prefix = labels + ","
+	}
+
+	for _, bucket := range prometheusBuckets {
+		fmt.Fprintf(w, "%s%s_bucket{%sle=\"%s\"} %d\n", metricsPrefix, name, prefix, formatFloat(bucket.Seconds()), min(h.CountAtOrBelow(bucket), count))
+	}
+	fmt.Fprintf(w, "%s%s_bucket{%sle=\"+Inf\"} %d\n", metricsPrefix, name, prefix, count)
+
+	suffix := ""
+	if labels != "" {
+		suffix = "{" + labels + "}"
+	}
+	fmt.Fprintf(w, "%s%s_sum%s %s\n", metricsPrefix, name, suffix, formatFloat(h.Sum().Seconds()))
+	fmt.Fprintf(w, "%s%s_count%s %d\n", metricsPrefix, name, suffix, count)
+}
+
+func stageLabel(stage *Collector) string {
+	return fmt.Sprintf("stage=\"%s\"", escapeLabelValue(stage.name))
+}
+
+// escapeLabelValue escapes the backslash, the double quote and the line feed in the label value.
+func escapeLabelValue(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,94 @@
+package collector
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	collector := New()
+	peak := collector.AddStage("peak")
+
+	peak.IncSuccessCount(3)
+	peak.IncRecordsCount(30)
+	peak.IncBytes(300, 100)
+	peak.RecordFailure(errors.New(`bad "quote"`))
+	peak.IncInFlight(2)
+	collector.SetTargetRate(12.5)
+	peak.ObserveLatency(3 * time.Millisecond)
+	peak.ObserveLatency(20 * time.Millisecond)
+	peak.ObserveLatency(20 * time.Second)
+
+	server := httptest.NewServer(collector.Handler())
+	defer server.Close()
+
+	resp, err := server.Client().Get(server.URL)
+	if err != nil {
+		t.Fatalf("failed to get the metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type: '%s'", contentType)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("failed to read the metrics: %v", err)
+	}
+
+	expected := []string{
+		"# TYPE o11ybench_requests_total counter",
+		`o11ybench_requests_total{result="success"} 3`,
+		`o11ybench_requests_total{result="failure"} 1`,
+		"o11ybench_records_total 30",
+		`o11ybench_sent_bytes_total{type="raw"} 300`,
+		`o11ybench_sent_bytes_total{type="wire"} 100`,
+		`o11ybench_failures_total{class="other"} 1`,
+		"o11ybench_in_flight_requests 2",
+		"o11ybench_target_rate 12.5",
+		"# TYPE o11ybench_request_latency_seconds histogram",
+		`o11ybench_request_latency_seconds_bucket{le="0.001"} 0`,
+		`o11ybench_request_latency_seconds_bucket{le="0.005"} 1`,
+		`o11ybench_request_latency_seconds_bucket{le="0.025"} 2`,
+		`o11ybench_request_latency_seconds_bucket{le="10"} 2`,
+		`o11ybench_request_latency_seconds_bucket{le="+Inf"} 3`,
+		"o11ybench_request_latency_seconds_count 3",
+		"# TYPE o11ybench_stage_request_latency_seconds histogram",
+		`o11ybench_stage_request_latency_seconds_bucket{stage="peak",le="+Inf"} 3`,
+		`o11ybench_stage_request_latency_seconds_count{stage="peak"} 3`,
+		`o11ybench_stage_requests_total{stage="peak",result="success"} 3`,
+	}
+	for _, line := range expected {
+		if !strings.Contains(string(body), line+"\n") {
+			t.Fatalf("the line '%s' is not found in the metrics:\n%s", line, body)
+		}
+	}
+
+	// The stage histograms must not be in the overall family, otherwise the requests are counted twice.
+	if strings.Contains(string(body), `o11ybench_request_latency_seconds_count{stage=`) {
+		t.Fatalf("the stage histograms are in the overall latency family:\n%s", body)
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{value: "HTTP 503", expected: "HTTP 503"},
+		{value: `a "b"`, expected: `a \"b\"`},
+		{value: `a\b`, expected: `a\\b`},
+		{value: "a\nb", expected: `a\nb`},
+	}
+
+	for _, tt := range tests {
+		if actual := escapeLabelValue(tt.value); actual != tt.expected {
+			t.Fatalf("escapeLabelValue('%s'): '%s', expected: '%s'", tt.value, actual, tt.expected)
+		}
+	}
+}
@@ -64,6 +64,21 @@ func (c *Config) Print() error {
 	return nil
 }
 
+// Map returns the config as a map with the same keys as the config file, for example, to embed the effective config into the report.
+func (c *Config) Map() (map[string]any, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[string]any)
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
 func (c *Config) validate() error {
 	if c.GeneratorConfig != nil {
 		if err := c.GeneratorConfig.Validate(); err != nil {
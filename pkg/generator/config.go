@@ -2,6 +2,7 @@ package generator
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/zyy17/o11ybench/pkg/generator/common"
 	"github.com/zyy17/o11ybench/pkg/generator/logs/types"
@@ -46,6 +47,9 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("only one generator can be set")
 		}
 		typ = GeneratorTypeMetrics
+		if err := c.Metrics.Validate(); err != nil {
+			return err
+		}
 	}
 
 	if typ == "" {
@@ -70,5 +74,13 @@ func (c Config) Defaults() *Config {
 		}
 	}
 
+	if c.Metrics != nil {
+		return &Config{
+			Metrics: &metrics.MetricsGeneratorConfig{
+				Interval: 15 * time.Second,
+			},
+		}
+	}
+
 	return &c
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/zyy17/o11ybench/pkg/generator/logs"
 	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
 )
 
 // Generator is the interface for the data generator.
@@ -15,13 +16,20 @@ type Generator interface {
 
 // GeneratorOptions is the options for configuring the data generation.
 type GeneratorOptions struct {
-	Logs *logstypes.GeneratorOptions
+	Logs    *logstypes.GeneratorOptions
+	Metrics *metrics.GeneratorOptions
 }
 
 // GeneratorOutput is the output of the data generation.
 type GeneratorOutput struct {
 	// Data is the generated data.
 	Data []byte
+
+	// Logs is the structured representation of the generated logs. It's only set by the logs generator.
+	Logs []*logstypes.LogRecord
+
+	// Metrics is the generated time series. It's only set by the metrics generator.
+	Metrics []*metrics.Series
 }
 
 // GeneratorType is the type of the generator.
@@ -39,8 +47,9 @@ const (
 )
 
 type generator struct {
-	typ  GeneratorType
-	logs *logs.LogsGenerator
+	typ     GeneratorType
+	logs    *logs.LogsGenerator
+	metrics *metrics.MetricsGenerator
 }
 
 var _ Generator = &generator{}
@@ -56,6 +65,15 @@ func New(cfg *Config) (Generator, error) {
 		return &generator{typ: GeneratorTypeLogs, logs: logsGenerator}, nil
 	}
 
+	if cfg.Metrics != nil {
+		metricsGenerator, err := metrics.NewMetricsGenerator(cfg.Metrics)
+		if err != nil {
+			return nil, err
+		}
+
+		return &generator{typ: GeneratorTypeMetrics, metrics: metricsGenerator}, nil
+	}
+
 	return nil, fmt.Errorf("invalid generator config")
 }
 
@@ -66,12 +84,25 @@ func (g *generator) Generate(opts *GeneratorOptions) (*GeneratorOutput, error) {
 			options = opts.Logs
 		}
 
-		data, err := g.logs.Generate(options)
+		records, err := g.logs.GenerateRecords(options)
+		if err != nil {
+			return nil, err
+		}
+
+		return &GeneratorOutput{Data: logstypes.JoinLines(records), Logs: records}, nil
+	}
+
+	if g.typ == GeneratorTypeMetrics {
+		if opts == nil || opts.Metrics == nil {
+			return nil, fmt.Errorf("metrics generator options are required")
+		}
+
+		series, err := g.metrics.Generate(opts.Metrics)
 		if err != nil {
 			return nil, err
 		}
 
-		return &GeneratorOutput{Data: data}, nil
+		return &GeneratorOutput{Data: metrics.TextFormat(series), Metrics: series}, nil
 	}
 
 	return nil, fmt.Errorf("no generator found")
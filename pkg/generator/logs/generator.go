@@ -25,32 +25,37 @@ func NewLogsGenerator(cfg *types.LogsGeneratorConfig, timeCfg *common.TimeConfig
 	return &LogsGenerator{cfg: cfg, timeCfg: timeCfg}, nil
 }
 
+// Generate generates the logs in the configured format by the given options. Each log is separated by a newline.
 func (g *LogsGenerator) Generate(opts *types.GeneratorOptions) ([]byte, error) {
+	records, err := g.GenerateRecords(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if records == nil {
+		return nil, nil
+	}
+
+	return types.JoinLines(records), nil
+}
+
+// GenerateRecords generates the structured logs by the given options.
+func (g *LogsGenerator) GenerateRecords(opts *types.GeneratorOptions) ([]*types.LogRecord, error) {
 	if opts != nil && opts.LogsCount > 0 {
 		if opts.Timestamp.IsZero() {
 			return nil, fmt.Errorf("timestamp is required")
 		}
 
-		logs, err := g.generateMultipleLogs(opts.LogsCount, opts.Timestamp, g.timeCfg)
-		if err != nil {
-			return nil, err
-		}
-
-		return logs, nil
+		return g.generateMultipleLogs(opts.LogsCount, opts.Timestamp, g.timeCfg)
 	}
 
 	if g.cfg.Output != nil {
 		if g.timeCfg == nil || g.timeCfg.Range == nil {
-			logs, err := g.generateMultipleLogs(g.cfg.Output.Count, time.Now(), g.timeCfg)
-			if err != nil {
-				return nil, err
-			}
-
-			return logs, nil
+			return g.generateMultipleLogs(g.cfg.Output.Count, time.Now(), g.timeCfg)
 		}
 
 		var (
-			logs  = make([]byte, 0)
+			logs  = make([]*types.LogRecord, 0)
 			start = g.timeCfg.Range.Start
 			end   = g.timeCfg.Range.End
 		)
@@ -68,10 +73,7 @@ func (g *LogsGenerator) Generate(opts *types.GeneratorOptions) ([]byte, error) {
 				return nil, err
 			}
 
-			// Add the newline to the log.
-			log = append(log, '\n')
-
-			logs = append(logs, log...)
+			logs = append(logs, log)
 
 			current = current.Add(g.cfg.Output.Interval)
 		}
@@ -82,8 +84,8 @@ func (g *LogsGenerator) Generate(opts *types.GeneratorOptions) ([]byte, error) {
 	return nil, nil
 }
 
-func (g *LogsGenerator) generateMultipleLogs(count int, timestamp time.Time, timeCfg *common.TimeConfig) ([]byte, error) {
-	logs := make([]byte, 0)
+func (g *LogsGenerator) generateMultipleLogs(count int, timestamp time.Time, timeCfg *common.TimeConfig) ([]*types.LogRecord, error) {
+	logs := make([]*types.LogRecord, 0, count)
 
 	for i := 0; i < count; i++ {
 		log, err := g.generateOneLineLog(timestamp, timeCfg)
@@ -91,31 +93,43 @@ func (g *LogsGenerator) generateMultipleLogs(count int, timestamp time.Time, tim
 			return nil, err
 		}
 
-		// Add the newline to the log.
-		log = append(log, '\n')
-
-		logs = append(logs, log...)
+		logs = append(logs, log)
 	}
 
 	return logs, nil
 }
 
-func (g *LogsGenerator) generateOneLineLog(timestamp time.Time, timeCfg *common.TimeConfig) ([]byte, error) {
+func (g *LogsGenerator) generateOneLineLog(timestamp time.Time, timeCfg *common.TimeConfig) (*types.LogRecord, error) {
 	// The logs tokens that are from the config.
 	generatedData, err := generateTokenValues(g.cfg.Tokens)
 	if err != nil {
 		return nil, err
 	}
 
+	record := &types.LogRecord{
+		Timestamp: timestamp,
+		Fields:    logFields(g.cfg.Tokens, generatedData),
+	}
+
 	// Set the timestamp.
 	generatedData[templates.ReservedTokenNameTimestamp] = common.OutputTimestamp(timestamp, timeCfg.TimestampFormat)
 
 	if g.cfg.Format.Type == types.LogFormatTypeJSON {
-		return g.jsonOutput(generatedData)
+		record.Line, err = g.jsonOutput(generatedData)
+		if err != nil {
+			return nil, err
+		}
+
+		return record, nil
 	}
 
 	if g.cfg.Format.Custom != "" {
-		return g.templateOutput(g.cfg.Format.Custom, generatedData)
+		record.Line, err = g.templateOutput(g.cfg.Format.Custom, generatedData)
+		if err != nil {
+			return nil, err
+		}
+
+		return record, nil
 	}
 
 	// The logs tokens that are from the builtin templates.
@@ -129,6 +143,7 @@ func (g *LogsGenerator) generateOneLineLog(timestamp time.Time, timeCfg *common.
 		if err != nil {
 			return nil, err
 		}
+		record.Fields = append(record.Fields, logFields(builtinTemplate.Tokens, builtinGeneratedData)...)
 
 		// Set the timestamp.
 		if timeCfg.TimestampFormat.Type == "" && timeCfg.TimestampFormat.Custom == "" && builtinTemplate.TimestampFormat != "" {
@@ -139,7 +154,12 @@ func (g *LogsGenerator) generateOneLineLog(timestamp time.Time, timeCfg *common.
 		// Merge the generated data with the builtin generated data.
 		maps.Copy(generatedData, builtinGeneratedData)
 
-		return g.templateOutput(builtinTemplate.Template, generatedData)
+		record.Line, err = g.templateOutput(builtinTemplate.Template, generatedData)
+		if err != nil {
+			return nil, err
+		}
+
+		return record, nil
 	}
 
 	return nil, fmt.Errorf("can't find a valid log format")
@@ -201,3 +221,23 @@ func generateTokenValues(tokens []*types.LogToken) (map[string]any, error) {
 
 	return generatedData, nil
 }
+
+// logFields converts the generated token values into the log fields in the order of the tokens.
+func logFields(tokens []*types.LogToken, generatedData map[string]any) []*types.LogField {
+	fields := make([]*types.LogField, 0, len(tokens))
+
+	for _, token := range tokens {
+		key := token.Name
+		if token.Display != "" {
+			key = token.Display
+		}
+
+		fields = append(fields, &types.LogField{
+			Name:  token.Name,
+			Key:   key,
+			Value: generatedData[token.Name],
+		})
+	}
+
+	return fields
+}
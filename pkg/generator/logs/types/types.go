@@ -108,3 +108,51 @@ type GeneratorOptions struct {
 	// Timestamp is the given timestamp of the log.
 	Timestamp time.Time
 }
+
+// LogRecord is the structured representation of a generated log.
+type LogRecord struct {
+	// Timestamp is the timestamp of the log.
+	Timestamp time.Time
+
+	// Fields is the list of the generated token values in the order of the token definitions. The timestamp is not included.
+	Fields []*LogField
+
+	// Line is the rendered log in the configured format without the trailing newline.
+	Line []byte
+}
+
+// LogField is the generated value of a token.
+type LogField struct {
+	// Name is the internal name of the token.
+	Name string
+
+	// Key is the output name of the token. It's the display name of the token if it's set, otherwise it's the name of the token.
+	Key string
+
+	// Value is the generated value of the token.
+	Value any
+}
+
+// Field returns the field by the given token name. It returns nil if the field is not found.
+func (r *LogRecord) Field(name string) *LogField {
+	for _, field := range r.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+
+	return nil
+}
+
+// JoinLines joins the rendered lines of the records. Each line ends with a newline.
+func JoinLines(records []*LogRecord) []byte {
+	data := make([]byte, 0)
+	for _, record := range records {
+		data = append(data, record.Line...)
+
+		// Add the newline to the log.
+		data = append(data, '\n')
+	}
+
+	return data
+}
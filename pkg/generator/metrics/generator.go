@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator/common"
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+)
+
+const (
+	// defaultMax is the default maximum of the gauge value or the increment of the counter.
+	defaultMax = 100
+
+	// maxLabelsRetries is the maximum number of retries to generate the distinct labels of a series.
+	maxLabelsRetries = 10
+)
+
+// MetricsGenerator is the generator for the metrics.
+type MetricsGenerator struct {
+	cfg    *MetricsGeneratorConfig
+	series []*seriesState
+
+	// cursor is used to pick the series in round-robin.
+	cursor atomic.Uint64
+}
+
+// seriesState is the state of a series. It keeps the value of the counter and the timestamp of the last sample.
+type seriesState struct {
+	mu sync.Mutex
+
+	metric        *Metric
+	labels        []*LabelPair
+	value         float64
+	lastTimestamp int64
+}
+
+// NewMetricsGenerator creates a new MetricsGenerator. The label values of all the series are generated in advance.
+func NewMetricsGenerator(cfg *MetricsGeneratorConfig) (*MetricsGenerator, error) {
+	g := &MetricsGenerator{cfg: cfg}
+
+	for _, metric := range cfg.Metrics {
+		seriesNum := metric.Series
+		if seriesNum <= 0 {
+			seriesNum = 1
+		}
+
+		seen := make(map[string]struct{}, seriesNum)
+		for i := 0; i < seriesNum; i++ {
+			labels, err := generateDistinctLabels(metric, i, seen)
+			if err != nil {
+				return nil, fmt.Errorf("failed to generate labels for metric '%s': %w", metric.Name, err)
+			}
+
+			g.series = append(g.series, &seriesState{metric: metric, labels: labels})
+		}
+	}
+
+	if len(g.series) == 0 {
+		return nil, fmt.Errorf("no series is defined")
+	}
+
+	return g, nil
+}
+
+// Generate generates the series by the given options. The series are picked in round-robin.
+func (g *MetricsGenerator) Generate(opts *GeneratorOptions) ([]*Series, error) {
+	if opts == nil || opts.SeriesCount <= 0 || opts.SamplesPerSeries <= 0 {
+		return nil, fmt.Errorf("series count and samples per series must be greater than 0")
+	}
+
+	if opts.Timestamp.IsZero() {
+		return nil, fmt.Errorf("timestamp is required")
+	}
+
+	series := make([]*Series, 0, opts.SeriesCount)
+	for i := 0; i < opts.SeriesCount; i++ {
+		state := g.series[(g.cursor.Add(1)-1)%uint64(len(g.series))]
+		series = append(series, state.generate(opts.SamplesPerSeries, opts.Timestamp, g.cfg.Interval))
+	}
+
+	return series, nil
+}
+
+// generate generates the samples of the series. The timestamps of the samples are always increasing even if the series is picked by multiple requests in a short time.
+// The series is shared by all the workers, so the timestamps only increase in the order of the generation. With `workers > 1`, the requests
+// that carry the same series are sent concurrently and may arrive at the target out of order, which the target can reject as the out-of-order samples.
+func (s *seriesState) generate(samplesNum int, timestamp time.Time, interval time.Duration) *Series {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		samples    = make([]*Sample, 0, samplesNum)
+		intervalMs = interval.Milliseconds()
+		min, max   = s.metric.bounds()
+	)
+
+	for i := 0; i < samplesNum; i++ {
+		ts := timestamp.UnixMilli() - int64(samplesNum-1-i)*intervalMs
+		if ts <= s.lastTimestamp {
+			ts = s.lastTimestamp + 1
+		}
+		s.lastTimestamp = ts
+
+		value := min + rand.Float64()*(max-min)
+		if s.metric.Type == MetricTypeCounter {
+			s.value += value
+			value = s.value
+		}
+
+		samples = append(samples, &Sample{Timestamp: ts, Value: value})
+	}
+
+	return &Series{Labels: s.labels, Type: s.metric.Type, Samples: samples}
+}
+
+// TextFormat outputs the series in the Prometheus text exposition format with the timestamps.
+func TextFormat(series []*Series) []byte {
+	var buf bytes.Buffer
+	for _, s := range series {
+		labels := labelsString(s.Labels)
+		for _, sample := range s.Samples {
+			buf.WriteString(labels)
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatFloat(sample.Value, 'f', -1, 64))
+			buf.WriteByte(' ')
+			buf.WriteString(strconv.FormatInt(sample.Timestamp, 10))
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes()
+}
+
+// labelsString returns the labels in the Prometheus format, for example, `cpu_usage{host="a"}`.
+func labelsString(labels []*LabelPair) string {
+	var (
+		name string
+		sb   strings.Builder
+	)
+	for _, label := range labels {
+		if label.Name == MetricNameLabel {
+			name = label.Value
+			continue
+		}
+
+		if sb.Len() > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(label.Name)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(label.Value))
+	}
+
+	if sb.Len() == 0 {
+		return name
+	}
+
+	return name + "{" + sb.String() + "}"
+}
+
+// generateDistinctLabels generates the labels that are different from the seen series.
+// If the faker can't generate the distinct label values, the `series_id` label will be added to keep the series distinct.
+func generateDistinctLabels(metric *Metric, id int, seen map[string]struct{}) ([]*LabelPair, error) {
+	for i := 0; i < maxLabelsRetries; i++ {
+		labels, err := generateLabels(metric)
+		if err != nil {
+			return nil, err
+		}
+
+		key := labelsString(labels)
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			return labels, nil
+		}
+	}
+
+	labels, err := generateLabels(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	labels = append(labels, &LabelPair{Name: SeriesIDLabel, Value: strconv.Itoa(id)})
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	return labels, nil
+}
+
+// generateLabels generates the labels of a series sorted by the name.
+func generateLabels(metric *Metric) ([]*LabelPair, error) {
+	labels := []*LabelPair{{Name: MetricNameLabel, Value: metric.Name}}
+
+	for _, label := range metric.Labels {
+		value := label.Value
+		if value == "" {
+			typ := label.Type
+			if typ == "" {
+				typ = common.ElementTypeString
+			}
+
+			fakeValue, err := faker.Fake(typ, label.FakeConfig)
+			if err != nil {
+				return nil, err
+			}
+			value = fmt.Sprintf("%v", fakeValue)
+		}
+
+		labels = append(labels, &LabelPair{Name: label.Name, Value: value})
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return labels[i].Name < labels[j].Name
+	})
+
+	return labels, nil
+}
@@ -0,0 +1,202 @@
+package metrics
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+)
+
+func TestMetricsGenerator(t *testing.T) {
+	maxValue := 10.0
+	cfg := &MetricsGeneratorConfig{
+		Interval: 15 * time.Second,
+		Metrics: []*Metric{
+			{
+				Name:   "http_requests_total",
+				Type:   MetricTypeCounter,
+				Series: 3,
+				Labels: []*Label{
+					{Name: "method", FakeConfig: &faker.FakeConfig{Kind: faker.FakeDataKindHTTPMethod}},
+					{Name: "job", Value: "o11ybench"},
+				},
+				Min: 1,
+				Max: &maxValue,
+			},
+		},
+	}
+
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	generator, err := NewMetricsGenerator(cfg)
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	var (
+		timestamp, _ = time.Parse(time.RFC3339, "2025-03-23T00:00:00Z")
+		opts         = &GeneratorOptions{SeriesCount: 3, SamplesPerSeries: 4, Timestamp: timestamp}
+		last         = make(map[string]*Sample)
+	)
+
+	// The series are generated twice with the same timestamp, and the samples should always be increasing.
+	for i := 0; i < 2; i++ {
+		series, err := generator.Generate(opts)
+		if err != nil {
+			t.Fatalf("failed to generate: %v", err)
+		}
+
+		if len(series) != opts.SeriesCount {
+			t.Fatalf("expected '%d' series, but got '%d'", opts.SeriesCount, len(series))
+		}
+
+		for _, s := range series {
+			if s.Labels[0].Name != MetricNameLabel || s.Labels[0].Value != "http_requests_total" {
+				t.Fatalf("unexpected labels '%v'", s.Labels)
+			}
+
+			if len(s.Samples) != opts.SamplesPerSeries {
+				t.Fatalf("expected '%d' samples, but got '%d'", opts.SamplesPerSeries, len(s.Samples))
+			}
+
+			key := labelsString(s.Labels)
+			for _, sample := range s.Samples {
+				if prev, ok := last[key]; ok && (sample.Timestamp <= prev.Timestamp || sample.Value <= prev.Value) {
+					t.Fatalf("sample '%v' is not increasing after '%v'", sample, prev)
+				}
+				last[key] = sample
+			}
+		}
+	}
+
+	if len(last) != cfg.Metrics[0].Series {
+		t.Fatalf("expected '%d' distinct series, but got '%d'", cfg.Metrics[0].Series, len(last))
+	}
+}
+
+func TestTextFormat(t *testing.T) {
+	series := []*Series{
+		{
+			Labels: []*LabelPair{
+				{Name: MetricNameLabel, Value: "cpu_usage"},
+				{Name: "host", Value: "a"},
+			},
+			Samples: []*Sample{{Timestamp: 1742688000000, Value: 12.5}},
+		},
+	}
+
+	expected := `cpu_usage{host="a"} 12.5 1742688000000`
+	if got := strings.TrimSpace(string(TextFormat(series))); got != expected {
+		t.Fatalf("expected '%s', but got '%s'", expected, got)
+	}
+}
+
+func TestMetricBounds(t *testing.T) {
+	var (
+		zero = 0.0
+		ten  = 10.0
+	)
+
+	tests := []struct {
+		name             string
+		min              float64
+		max              *float64
+		wantMin, wantMax float64
+		wantErr          bool
+	}{
+		{name: "default", wantMin: 0, wantMax: 100},
+		{name: "only min", min: 50, wantMin: 50, wantMax: 100},
+		{name: "only max", max: &ten, wantMin: 0, wantMax: 10},
+		{name: "negative min and zero max", min: -10, max: &zero, wantMin: -10, wantMax: 0},
+		{name: "min greater than the default max", min: 500, wantErr: true},
+		{name: "min greater than max", min: 20, max: &ten, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metric := &Metric{Name: "test", Min: tt.min, Max: tt.max}
+			if err := metric.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if min, max := metric.bounds(); min != tt.wantMin || max != tt.wantMax {
+				t.Fatalf("unexpected bounds: '%f', '%f', expected: '%f', '%f'", min, max, tt.wantMin, tt.wantMax)
+			}
+
+			// The generated values are always in the bounds.
+			state := &seriesState{metric: metric}
+			for _, sample := range state.generate(100, time.Now(), time.Second).Samples {
+				if sample.Value < tt.wantMin || sample.Value > tt.wantMax {
+					t.Fatalf("the value '%f' is out of the bounds", sample.Value)
+				}
+			}
+		})
+	}
+}
+
+func TestMetricsGeneratorConcurrent(t *testing.T) {
+	generator, err := NewMetricsGenerator(&MetricsGeneratorConfig{
+		Interval: 15 * time.Second,
+		Metrics: []*Metric{
+			{Name: "cpu_usage", Type: MetricTypeGauge, Series: 2},
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create generator: %v", err)
+	}
+
+	// The workers generate the same series concurrently with the same timestamp.
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		timestamp = time.Now()
+		generated = make(map[string][][]*Sample)
+	)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				series, err := generator.Generate(&GeneratorOptions{SeriesCount: 2, SamplesPerSeries: 3, Timestamp: timestamp})
+				if err != nil {
+					t.Errorf("failed to generate: %v", err)
+					return
+				}
+
+				mu.Lock()
+				for _, s := range series {
+					key := labelsString(s.Labels)
+					generated[key] = append(generated[key], s.Samples)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The samples of each generation are increasing and don't overlap with the other generations of the series,
+	// so the samples are only out of order if the requests are sent in a different order from the generation.
+	for key, batches := range generated {
+		sort.Slice(batches, func(i, j int) bool {
+			return batches[i][0].Timestamp < batches[j][0].Timestamp
+		})
+
+		last := int64(0)
+		for _, samples := range batches {
+			for _, sample := range samples {
+				if sample.Timestamp <= last {
+					t.Fatalf("the samples of '%s' overlap: '%d' after '%d'", key, sample.Timestamp, last)
+				}
+				last = sample.Timestamp
+			}
+		}
+	}
+}
@@ -1,5 +1,172 @@
 package metrics
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator/common"
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+)
+
 // MetricsGeneratorConfig is the configuration for the metrics generator.
-// TODO(zyy17): Not implemented yet.
-type MetricsGeneratorConfig struct{}
+type MetricsGeneratorConfig struct {
+	// Metrics is the list of the metrics to be generated.
+	Metrics []*Metric `yaml:"metrics"`
+
+	// Interval is the interval between the samples of a series. Default is `15s`.
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// Metric is the definition of a metric and its series.
+type Metric struct {
+	// Name is the name of the metric. For example: `http_requests_total`.
+	Name string `yaml:"name"`
+
+	// Type is the type of the metric. Default is `gauge`.
+	Type MetricType `yaml:"type,omitempty"`
+
+	// Series is the number of the distinct series of the metric. Default is `1`.
+	// The label values of each series are generated once when the generator is created, so the series are stable during the benchmark.
+	// If the generated label values are duplicated, the `series_id` label will be added to keep the series distinct.
+	Series int `yaml:"series,omitempty"`
+
+	// Labels is the list of the labels of the metric.
+	Labels []*Label `yaml:"labels,omitempty"`
+
+	// Min is the minimum of the gauge value or the increment of the counter. Default is `0`.
+	Min float64 `yaml:"min,omitempty"`
+
+	// Max is the maximum of the gauge value or the increment of the counter. Default is `100`.
+	Max *float64 `yaml:"max,omitempty"`
+}
+
+// bounds returns the min and the max of the value. Each bound is defaulted on its own.
+func (m *Metric) bounds() (float64, float64) {
+	if m.Max == nil {
+		return m.Min, defaultMax
+	}
+
+	return m.Min, *m.Max
+}
+
+// Label is the label of the metric.
+type Label struct {
+	// Name is the name of the label.
+	Name string `yaml:"name"`
+
+	// Type is the type of the label value. Default is `string`.
+	Type common.ElementType `yaml:"type,omitempty"`
+
+	// FakeConfig is the configuration for how to generate the fake label value.
+	FakeConfig *faker.FakeConfig `yaml:"fake,omitempty"`
+
+	// Value is the value of the label. If this is set, the value will not be generated by the faker.
+	Value string `yaml:"value,omitempty"`
+}
+
+// MetricType is the type of the metric.
+type MetricType string
+
+const (
+	// MetricTypeGauge is the gauge metric whose value is random in [min, max).
+	MetricTypeGauge MetricType = "gauge"
+
+	// MetricTypeCounter is the counter metric whose value increases by a random increment in [min, max).
+	MetricTypeCounter MetricType = "counter"
+)
+
+const (
+	// MetricNameLabel is the reserved label name of the metric name.
+	MetricNameLabel = "__name__"
+
+	// SeriesIDLabel is the label to keep the series distinct if the generated label values are duplicated.
+	SeriesIDLabel = "series_id"
+)
+
+// Validate validates the configuration for the metrics generator.
+func (c *MetricsGeneratorConfig) Validate() error {
+	if len(c.Metrics) == 0 {
+		return fmt.Errorf("metrics are required")
+	}
+
+	for _, metric := range c.Metrics {
+		if err := metric.validate(); err != nil {
+			return fmt.Errorf("invalid metric '%s': %w", metric.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (m *Metric) validate() error {
+	if m.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if m.Type != "" && m.Type != MetricTypeGauge && m.Type != MetricTypeCounter {
+		return fmt.Errorf("invalid type: '%s'", m.Type)
+	}
+
+	if m.Series < 0 {
+		return fmt.Errorf("series must be greater than 0")
+	}
+
+	if min, max := m.bounds(); min > max {
+		return fmt.Errorf("min '%f' is greater than max '%f'", min, max)
+	}
+
+	for _, label := range m.Labels {
+		if label.Name == "" {
+			return fmt.Errorf("label name is required")
+		}
+
+		if label.Name == MetricNameLabel || label.Name == SeriesIDLabel {
+			return fmt.Errorf("label name '%s' is reserved", label.Name)
+		}
+
+		if label.Value == "" && label.FakeConfig == nil {
+			return fmt.Errorf("value or fake config is required for label '%s'", label.Name)
+		}
+	}
+
+	return nil
+}
+
+// GeneratorOptions is used to control the data volume of the metrics. It's only used for Loader.
+type GeneratorOptions struct {
+	// SeriesCount is the number of the series to generate.
+	SeriesCount int
+
+	// SamplesPerSeries is the number of the samples of each series.
+	SamplesPerSeries int
+
+	// Timestamp is the timestamp of the latest sample.
+	Timestamp time.Time
+}
+
+// Series is a generated time series.
+type Series struct {
+	// Labels is the labels of the series sorted by the name. It includes the `__name__` label.
+	Labels []*LabelPair
+
+	// Type is the type of the metric of the series. It's `gauge` if not set.
+	Type MetricType
+
+	// Samples is the samples of the series in the ascending order of the timestamp.
+	Samples []*Sample
+}
+
+// LabelPair is the name and the value of a label.
+type LabelPair struct {
+	Name  string
+	Value string
+}
+
+// Sample is a sample of the series.
+type Sample struct {
+	// Timestamp is the timestamp of the sample in milliseconds.
+	Timestamp int64
+
+	// Value is the value of the sample.
+	Value float64
+}
@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultAPIKeyHeader is the default header of the API key.
+const defaultAPIKeyHeader = "X-API-Key"
+
+// authProvider provides the authentication header of the requests. The header is resolved at the start and refreshed periodically if it's configured.
+type authProvider struct {
+	cfg  *AuthConfig
+	name string
+
+	// value is the current value of the header.
+	value atomic.Pointer[string]
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func newAuthProvider(cfg *AuthConfig) (*authProvider, error) {
+	p := &authProvider{cfg: cfg, name: "Authorization", stop: make(chan struct{})}
+	if cfg.APIKey != nil {
+		p.name = defaultAPIKeyHeader
+		if cfg.APIKey.Header != "" {
+			p.name = cfg.APIKey.Header
+		}
+	}
+
+	if err := p.refresh(); err != nil {
+		return nil, err
+	}
+
+	if cfg.RefreshInterval > 0 {
+		p.wg.Add(1)
+		go p.refreshLoop()
+	}
+
+	return p, nil
+}
+
+// header returns the name and the current value of the authentication header.
+func (p *authProvider) header() (string, string) {
+	return p.name, *p.value.Load()
+}
+
+func (p *authProvider) close() {
+	close(p.stop)
+	p.wg.Wait()
+}
+
+func (p *authProvider) refreshLoop() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.cfg.RefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			// The previous credential is kept if the refresh fails, for example, the token file is being replaced.
+			if err := p.refresh(); err != nil {
+				fmt.Printf("failed to refresh the auth credential: %v\n", err)
+			}
+		}
+	}
+}
+
+// refresh resolves the credentials again and updates the value of the header.
+func (p *authProvider) refresh() error {
+	var value string
+	switch {
+	case p.cfg.Basic != nil:
+		password, err := p.cfg.Basic.Password.resolve()
+		if err != nil {
+			return err
+		}
+		value = "Basic " + base64.StdEncoding.EncodeToString([]byte(p.cfg.Basic.Username+":"+password))
+	case p.cfg.Bearer != nil:
+		token, err := p.cfg.Bearer.Token.resolve()
+		if err != nil {
+			return err
+		}
+		value = "Bearer " + token
+	default:
+		key, err := p.cfg.APIKey.Key.resolve()
+		if err != nil {
+			return err
+		}
+		value = key
+	}
+
+	p.value.Store(&value)
+	return nil
+}
+
+// resolve returns the value of the credential from the literal, the environment variable or the file.
+func (c *SecretConfig) resolve() (string, error) {
+	switch {
+	case c.Env != "":
+		value, ok := os.LookupEnv(c.Env)
+		if !ok || value == "" {
+			return "", fmt.Errorf("the environment variable '%s' of the credential is not set", c.Env)
+		}
+		return value, nil
+	case c.File != "":
+		data, err := os.ReadFile(c.File)
+		if err != nil {
+			return "", fmt.Errorf("failed to read the credential file: %w", err)
+		}
+
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return "", fmt.Errorf("the credential file '%s' is empty", c.File)
+		}
+		return value, nil
+	default:
+		return c.Value, nil
+	}
+}
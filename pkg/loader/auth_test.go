@@ -0,0 +1,167 @@
+package loader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+func TestAuthProvider(t *testing.T) {
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	if err := os.WriteFile(tokenFile, []byte("file-token\n"), 0600); err != nil {
+		t.Fatalf("failed to write the token file: %v", err)
+	}
+	t.Setenv("O11YBENCH_TEST_PASSWORD", "secret")
+
+	tests := []struct {
+		name          string
+		cfg           *AuthConfig
+		expectedName  string
+		expectedValue string
+	}{
+		{
+			name:          "basic with env",
+			cfg:           &AuthConfig{Basic: &BasicAuthConfig{Username: "admin", Password: &SecretConfig{Env: "O11YBENCH_TEST_PASSWORD"}}},
+			expectedName:  "Authorization",
+			expectedValue: "Basic YWRtaW46c2VjcmV0",
+		},
+		{
+			name:          "bearer with file",
+			cfg:           &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{File: tokenFile}}},
+			expectedName:  "Authorization",
+			expectedValue: "Bearer file-token",
+		},
+		{
+			name:          "api key with default header",
+			cfg:           &AuthConfig{APIKey: &APIKeyAuthConfig{Key: &SecretConfig{Value: "key"}}},
+			expectedName:  "X-API-Key",
+			expectedValue: "key",
+		},
+		{
+			name:          "api key with custom header",
+			cfg:           &AuthConfig{APIKey: &APIKeyAuthConfig{Header: "X-Scope-Token", Key: &SecretConfig{Env: "O11YBENCH_TEST_PASSWORD"}}},
+			expectedName:  "X-Scope-Token",
+			expectedValue: "secret",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			p, err := newAuthProvider(tt.cfg)
+			if err != nil {
+				t.Fatalf("failed to create the auth provider: %v", err)
+			}
+			defer p.close()
+
+			name, value := p.header()
+			if name != tt.expectedName || value != tt.expectedValue {
+				t.Fatalf("unexpected header: '%s: %s', expected: '%s: %s'", name, value, tt.expectedName, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestAuthProviderRefresh(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("token-1"), 0600); err != nil {
+		t.Fatalf("failed to write the token file: %v", err)
+	}
+
+	received := make(chan string, 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get("Authorization")
+	}))
+	defer server.Close()
+
+	port, err := strconv.Atoi(server.URL[strings.LastIndex(server.URL, ":")+1:])
+	if err != nil {
+		t.Fatalf("failed to parse the port: %v", err)
+	}
+
+	cfg := HTTPConfig{}.defaults()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = port
+	cfg.URI = "/"
+	cfg.Method = http.MethodPost
+	cfg.Auth = &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{File: tokenFile}}, RefreshInterval: 10 * time.Millisecond}
+
+	sender, err := newHTTPSender(cfg, &rawEncoder{})
+	if err != nil {
+		t.Fatalf("failed to create the sender: %v", err)
+	}
+	defer sender.close()
+
+	send := func() string {
+		if _, err := sender.send(context.Background(), &worker{}, &generator.GeneratorOutput{Data: []byte("hello")}); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+		return <-received
+	}
+
+	if header := send(); header != "Bearer token-1" {
+		t.Fatalf("unexpected header: '%s'", header)
+	}
+
+	if err := os.WriteFile(tokenFile, []byte("token-2"), 0600); err != nil {
+		t.Fatalf("failed to write the token file: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for send() != "Bearer token-2" {
+		if time.Now().After(deadline) {
+			t.Fatalf("the token is not refreshed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuthConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *AuthConfig
+		wantErr bool
+	}{
+		{name: "bearer", cfg: &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{Env: "TOKEN"}}}},
+		{name: "no method", cfg: &AuthConfig{}, wantErr: true},
+		{name: "multiple methods", cfg: &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{Value: "a"}}, APIKey: &APIKeyAuthConfig{Key: &SecretConfig{Value: "b"}}}, wantErr: true},
+		{name: "basic without username", cfg: &AuthConfig{Basic: &BasicAuthConfig{Password: &SecretConfig{Value: "a"}}}, wantErr: true},
+		{name: "missing secret", cfg: &AuthConfig{Bearer: &BearerAuthConfig{}}, wantErr: true},
+		{name: "multiple sources", cfg: &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{Env: "TOKEN", File: "token"}}}, wantErr: true},
+		{name: "negative refresh interval", cfg: &AuthConfig{Bearer: &BearerAuthConfig{Token: &SecretConfig{Value: "a"}}, RefreshInterval: -time.Second}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.cfg.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSecretConfigResolve(t *testing.T) {
+	if _, err := (&SecretConfig{Env: "O11YBENCH_TEST_UNSET"}).resolve(); err == nil {
+		t.Fatalf("expected the error of the unset environment variable")
+	}
+
+	emptyFile := filepath.Join(t.TempDir(), "empty")
+	if err := os.WriteFile(emptyFile, []byte("\n"), 0600); err != nil {
+		t.Fatalf("failed to write the file: %v", err)
+	}
+	if _, err := (&SecretConfig{File: emptyFile}).resolve(); err == nil {
+		t.Fatalf("expected the error of the empty file")
+	}
+}
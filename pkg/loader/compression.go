@@ -0,0 +1,163 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+const (
+	// CompressionGzip compresses the payload by gzip. The level is from 1(best speed) to 9(best compression).
+	CompressionGzip = "gzip"
+
+	// CompressionZstd compresses the payload by zstd. The level is the zstd level from 1 to 22, which is mapped to the closest level of the encoder.
+	CompressionZstd = "zstd"
+
+	// CompressionSnappy compresses the payload by the snappy block format. The level can't be set.
+	CompressionSnappy = "snappy"
+
+	// CompressionSnappyFramed compresses the payload by the snappy framing format. The level can't be set.
+	CompressionSnappyFramed = "snappyFramed"
+
+	// CompressionLZ4 compresses the payload by the lz4 frame format. The level is from 1(best speed) to 9(best compression).
+	CompressionLZ4 = "lz4"
+
+	// CompressionDeflate compresses the payload by the zlib format, which is the `deflate` content coding of HTTP. The level is from 1(best speed) to 9(best compression).
+	CompressionDeflate = "deflate"
+)
+
+// compressor compresses the payload of the requests.
+type compressor struct {
+	name  string
+	level int
+
+	// zstd is the shared zstd encoder, which is safe for the concurrent use by `EncodeAll`.
+	zstd *zstd.Encoder
+}
+
+func newCompressor(name string, level int) (*compressor, error) {
+	if err := validateCompression(name, level); err != nil {
+		return nil, err
+	}
+
+	c := &compressor{name: name, level: level}
+	if name == CompressionZstd {
+		var opts []zstd.EOption
+		if level > 0 {
+			opts = append(opts, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+		}
+
+		encoder, err := zstd.NewWriter(nil, opts...)
+		if err != nil {
+			return nil, err
+		}
+		c.zstd = encoder
+	}
+
+	return c, nil
+}
+
+// contentEncoding returns the value of the `Content-Encoding` header.
+func (c *compressor) contentEncoding() string {
+	if c.name == CompressionSnappyFramed {
+		return "x-snappy-framed"
+	}
+
+	return c.name
+}
+
+// close releases the shared encoder.
+func (c *compressor) close() {
+	if c.zstd != nil {
+		c.zstd.Close()
+	}
+}
+
+// compress compresses the data into the buffer.
+func (c *compressor) compress(buf *bytes.Buffer, data []byte) error {
+	switch c.name {
+	case CompressionZstd:
+		buf.Write(c.zstd.EncodeAll(data, nil))
+		return nil
+	case CompressionSnappy:
+		buf.Write(snappy.Encode(nil, data))
+		return nil
+	}
+
+	var (
+		w   io.WriteCloser
+		err error
+	)
+	switch c.name {
+	case CompressionGzip:
+		w, err = gzip.NewWriterLevel(buf, c.flateLevel())
+	case CompressionDeflate:
+		w, err = zlib.NewWriterLevel(buf, c.flateLevel())
+	case CompressionSnappyFramed:
+		w = snappy.NewBufferedWriter(buf)
+	case CompressionLZ4:
+		lw := lz4.NewWriter(buf)
+		if c.level > 0 {
+			err = lw.Apply(lz4.CompressionLevelOption(lz4.CompressionLevel(1 << (8 + c.level))))
+		}
+		w = lw
+	default:
+		err = fmt.Errorf("unsupported compression '%s'", c.name)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+// flateLevel returns the level of gzip and deflate. The default level is used if the level is not set.
+func (c *compressor) flateLevel() int {
+	if c.level == 0 {
+		return gzip.DefaultCompression
+	}
+
+	return c.level
+}
+
+// validateCompression validates the compression and its level. The level 0 means the default level of the compression.
+func validateCompression(name string, level int) error {
+	if level < 0 {
+		return fmt.Errorf("compression level must be greater than or equal to 0")
+	}
+
+	switch name {
+	case CompressionGzip, CompressionDeflate, CompressionLZ4:
+		if level > 9 {
+			return fmt.Errorf("compression level of '%s' must be between 1 and 9", name)
+		}
+	case CompressionZstd:
+		if level > 22 {
+			return fmt.Errorf("compression level of '%s' must be between 1 and 22", name)
+		}
+	case CompressionSnappy, CompressionSnappyFramed:
+		if level != 0 {
+			return fmt.Errorf("compression level can't be set for '%s'", name)
+		}
+	case "":
+		if level != 0 {
+			return fmt.Errorf("compression level can't be set without the compression")
+		}
+	default:
+		return fmt.Errorf("unsupported compression '%s', options available are '%s', '%s', '%s', '%s', '%s' and '%s'",
+			name, CompressionGzip, CompressionZstd, CompressionSnappy, CompressionSnappyFramed, CompressionLZ4, CompressionDeflate)
+	}
+
+	return nil
+}
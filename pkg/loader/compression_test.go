@@ -0,0 +1,114 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+func TestCompressor(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"level":"info","message":"hello world"}`+"\n"), 100)
+
+	tests := []struct {
+		name             string
+		level            int
+		expectedEncoding string
+		decompress       func(data []byte) ([]byte, error)
+	}{
+		{name: CompressionGzip, level: 9, expectedEncoding: "gzip", decompress: func(data []byte) ([]byte, error) {
+			r, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		}},
+		{name: CompressionDeflate, expectedEncoding: "deflate", decompress: func(data []byte) ([]byte, error) {
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			return io.ReadAll(r)
+		}},
+		{name: CompressionZstd, level: 19, expectedEncoding: "zstd", decompress: func(data []byte) ([]byte, error) {
+			d, err := zstd.NewReader(nil)
+			if err != nil {
+				return nil, err
+			}
+			defer d.Close()
+			return d.DecodeAll(data, nil)
+		}},
+		{name: CompressionSnappy, expectedEncoding: "snappy", decompress: func(data []byte) ([]byte, error) {
+			return snappy.Decode(nil, data)
+		}},
+		{name: CompressionSnappyFramed, expectedEncoding: "x-snappy-framed", decompress: func(data []byte) ([]byte, error) {
+			return io.ReadAll(snappy.NewReader(bytes.NewReader(data)))
+		}},
+		{name: CompressionLZ4, level: 5, expectedEncoding: "lz4", decompress: func(data []byte) ([]byte, error) {
+			return io.ReadAll(lz4.NewReader(bytes.NewReader(data)))
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := newCompressor(tt.name, tt.level)
+			if err != nil {
+				t.Fatalf("failed to create the compressor: %v", err)
+			}
+			defer c.close()
+
+			if c.contentEncoding() != tt.expectedEncoding {
+				t.Fatalf("unexpected content encoding: '%s', expected: '%s'", c.contentEncoding(), tt.expectedEncoding)
+			}
+
+			var buf bytes.Buffer
+			if err := c.compress(&buf, data); err != nil {
+				t.Fatalf("failed to compress: %v", err)
+			}
+
+			if buf.Len() >= len(data) {
+				t.Fatalf("the data is not compressed: '%d' >= '%d'", buf.Len(), len(data))
+			}
+
+			decompressed, err := tt.decompress(buf.Bytes())
+			if err != nil {
+				t.Fatalf("failed to decompress: %v", err)
+			}
+
+			if !bytes.Equal(decompressed, data) {
+				t.Fatalf("the decompressed data doesn't match the original data")
+			}
+		})
+	}
+}
+
+func TestValidateCompression(t *testing.T) {
+	tests := []struct {
+		name    string
+		level   int
+		wantErr bool
+	}{
+		{name: "", level: 0},
+		{name: "", level: 1, wantErr: true},
+		{name: CompressionGzip, level: 0},
+		{name: CompressionGzip, level: 9},
+		{name: CompressionGzip, level: 10, wantErr: true},
+		{name: CompressionZstd, level: 22},
+		{name: CompressionZstd, level: 23, wantErr: true},
+		{name: CompressionLZ4, level: -1, wantErr: true},
+		{name: CompressionSnappy, level: 1, wantErr: true},
+		{name: CompressionSnappyFramed, level: 0},
+		{name: "brotli", level: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		if err := validateCompression(tt.name, tt.level); (err != nil) != tt.wantErr {
+			t.Fatalf("validateCompression('%s', %d): '%v', wantErr: '%v'", tt.name, tt.level, err, tt.wantErr)
+		}
+	}
+}
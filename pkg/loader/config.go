@@ -2,26 +2,155 @@ package loader
 
 import (
 	"fmt"
+	"net/http"
 	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator/common"
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+	"github.com/zyy17/o11ybench/pkg/utils"
 )
 
 // Config is the configuration for the loader.
 type Config struct {
-	// Rate is the number of requests that will be made per second.
-	Rate int `yaml:"rate"`
+	// Rate is the number of requests that will be made per second. It can be fractional, for example, `0.5` makes a request every 2 seconds.
+	// The requests are scheduled on the timeline of the intended start times, so a slow target will not reduce the offered load.
+	Rate float64 `yaml:"rate"`
+
+	// Arrival is the distribution of the intended start times of the requests. Options available are `constant` and `poisson`. Default is `constant`.
+	// `constant` makes the requests at the fixed interval, and `poisson` makes the requests with the exponentially distributed intervals in the same mean rate.
+	Arrival Arrival `yaml:"arrival,omitempty"`
 
-	// Workers is the number of workers that will be used to make the requests. Default is `2`.
+	// Workers is the max number of the concurrent requests. If all the workers are busy, the scheduled requests will wait and the send lag will grow. Default is `2`.
 	Workers int `yaml:"workers,omitempty"`
 
 	// Duration is the duration of the stress test. For example: `1min`.
 	// If not set, the test will keep running until the interrupt signal is received.
 	Duration time.Duration `yaml:"duration,omitempty"`
 
+	// Profile is the load profile that changes the rate over time by stages.
+	// If set, `rate` is not used and the duration of the test is the sum of the durations of the stages, so `duration` can't be set.
+	Profile *ProfileConfig `yaml:"profile,omitempty"`
+
+	// Retry is the retry policy of the failed requests. If not set, the failed requests are not retried.
+	Retry *RetryConfig `yaml:"retry,omitempty"`
+
+	// Pool pre-generates a pool of the payloads before the run and cycles through them, so the cost of the generator is not measured as the cost of the ingestion.
+	// If not set, the payload of each request is generated when it's sent.
+	Pool *PoolConfig `yaml:"pool,omitempty"`
+
+	// Protocol is the protocol of the payload that will be sent to the target. Default is `raw`.
+	Protocol Protocol `yaml:"protocol,omitempty"`
+
 	// HTTP is the configuration for the HTTP requests.
-	HTTP HTTPConfig `yaml:"http"`
+	HTTP *HTTPConfig `yaml:"http,omitempty"`
+
+	// GRPC is the configuration for the OTLP gRPC requests.
+	GRPC *GRPCConfig `yaml:"grpc,omitempty"`
+
+	// Syslog is the configuration for sending the logs to the syslog receiver.
+	Syslog *SyslogConfig `yaml:"syslog,omitempty"`
+
+	// Forward is the configuration for sending the logs by the Fluent Forward protocol.
+	Forward *ForwardConfig `yaml:"forward,omitempty"`
+
+	// File is the configuration for writing the logs into the files on disk, which is used to benchmark the agents that tail the files.
+	// Only one of `http`, `grpc`, `syslog`, `forward` and `file` can be set.
+	File *FileConfig `yaml:"file,omitempty"`
+
+	// OTLP is the configuration for the OTLP protocol. It's only used when the protocol is `otlp`.
+	OTLP *OTLPConfig `yaml:"otlp,omitempty"`
+
+	// Loki is the configuration for the Loki push protocol. It's only used when the protocol is `loki`.
+	Loki *LokiConfig `yaml:"loki,omitempty"`
+
+	// Elasticsearch is the configuration for the Elasticsearch `_bulk` protocol. It's only used when the protocol is `elasticsearch`.
+	Elasticsearch *ElasticsearchConfig `yaml:"elasticsearch,omitempty"`
 
 	// Logs is the configuration for controlling the volume of data generated by the LogsGenerator during load testing.
 	Logs *LogsGeneratorConfig `yaml:"logs,omitempty"`
+
+	// Metrics is the configuration for controlling the volume of data generated by the MetricsGenerator during load testing.
+	// Only one of `logs` and `metrics` can be set.
+	Metrics *MetricsGeneratorConfig `yaml:"metrics,omitempty"`
+}
+
+// Protocol is the protocol of the payload that will be sent to the target.
+type Protocol string
+
+const (
+	// ProtocolRaw sends the generated data as the request body without any conversion.
+	ProtocolRaw Protocol = "raw"
+
+	// ProtocolOTLP encodes the generated logs into the OTLP `ExportLogsServiceRequest` in protobuf.
+	ProtocolOTLP Protocol = "otlp"
+
+	// ProtocolLoki encodes the generated logs into the Loki `PushRequest` streams.
+	ProtocolLoki Protocol = "loki"
+
+	// ProtocolElasticsearch encodes the generated JSON logs into the Elasticsearch/OpenSearch `_bulk` request.
+	ProtocolElasticsearch Protocol = "elasticsearch"
+
+	// ProtocolRemoteWrite encodes the generated metrics into the Prometheus remote-write v1 `WriteRequest` in snappy-compressed protobuf.
+	ProtocolRemoteWrite Protocol = "remoteWrite"
+)
+
+// OTLPConfig is the configuration for the OTLP protocol.
+type OTLPConfig struct {
+	// ResourceAttributes is the multiple key-value pairs of the resource attributes. Default is `service.name: o11ybench`.
+	ResourceAttributes map[string]string `yaml:"resourceAttributes,omitempty"`
+
+	// ScopeName is the name of the instrumentation scope. Default is `o11ybench`.
+	ScopeName string `yaml:"scopeName,omitempty"`
+
+	// ScopeVersion is the version of the instrumentation scope.
+	ScopeVersion string `yaml:"scopeVersion,omitempty"`
+
+	// BodyToken is the name of the token whose value will be used as the body of the log record.
+	// If not set, the rendered log line will be used as the body and all the tokens will be the attributes.
+	BodyToken string `yaml:"bodyToken,omitempty"`
+
+	// SeverityToken is the name of the token whose value will be used as the severity of the log record. For example: `logLevel`.
+	SeverityToken string `yaml:"severityToken,omitempty"`
+}
+
+// LokiConfig is the configuration for the Loki push protocol.
+type LokiConfig struct {
+	// Format is the encoding format of the push request. Options available are `protobuf`(snappy-compressed) and `json`. Default is `protobuf`.
+	Format LokiFormat `yaml:"format,omitempty"`
+
+	// Labels is the multiple key-value pairs of the static labels for all the streams. Default is `job: o11ybench`.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// LabelTokens is the list of the token names whose values will be used as the stream labels.
+	// The label name is the display name of the token if it's set, otherwise it's the name of the token.
+	LabelTokens []string `yaml:"labelTokens,omitempty"`
+
+	// MaxLabelValues is the maximum number of the distinct values for each label token.
+	// If it's set, the values of each label token will be limited to the first `MaxLabelValues` distinct values. It's useful to control the cardinality of the streams.
+	MaxLabelValues int `yaml:"maxLabelValues,omitempty"`
+}
+
+// LokiFormat is the encoding format of the Loki push request.
+type LokiFormat string
+
+const (
+	// LokiFormatProtobuf is the snappy-compressed protobuf format.
+	LokiFormatProtobuf LokiFormat = "protobuf"
+
+	// LokiFormatJSON is the JSON format.
+	LokiFormatJSON LokiFormat = "json"
+)
+
+// ElasticsearchConfig is the configuration for the Elasticsearch `_bulk` protocol.
+type ElasticsearchConfig struct {
+	// Index is the target index of the documents. Default is `o11ybench`.
+	// You can use the template syntax to generate the index by the tokens and the timestamp of each log.
+	// For example: `logs-{{ .level }}-{{ .Timestamp.Format "2006.01.02" }}`.
+	Index string `yaml:"index,omitempty"`
+
+	// Action is the bulk action of each document. Options available are `create` and `index`. Default is `create`.
+	// The data streams only accept the `create` action.
+	Action string `yaml:"action,omitempty"`
 }
 
 // LogsGeneratorConfig is the configuration for controlling the volume of data generated by the LogsGenerator during load testing.
@@ -38,8 +167,175 @@ func (c *LogsGeneratorConfig) validate() error {
 	return nil
 }
 
+// MetricsGeneratorConfig is the configuration for controlling the volume of data generated by the MetricsGenerator during load testing.
+// The series are shared by the workers, so with more than one worker, the samples of a series may arrive at the target out of order.
+type MetricsGeneratorConfig struct {
+	// SeriesPerRequest is the number of the series in each request.
+	SeriesPerRequest int `yaml:"seriesPerRequest,omitempty"`
+
+	// SamplesPerSeries is the number of the samples of each series in each request. Default is `1`.
+	SamplesPerSeries int `yaml:"samplesPerSeries,omitempty"`
+}
+
+func (c *MetricsGeneratorConfig) validate() error {
+	if c.SeriesPerRequest <= 0 {
+		return fmt.Errorf("seriesPerRequest must be greater than 0")
+	}
+
+	if c.SamplesPerSeries <= 0 {
+		return fmt.Errorf("samplesPerSeries must be greater than 0")
+	}
+
+	return nil
+}
+
+// ProfileConfig is the configuration of the load profile. The stages are run in order.
+type ProfileConfig struct {
+	// Stages is the list of the stages of the load profile.
+	Stages []*StageConfig `yaml:"stages"`
+}
+
+// StageConfig is the configuration of a stage of the load profile.
+type StageConfig struct {
+	// Name is the name of the stage in the stats. Default is `stage-<index>`.
+	Name string `yaml:"name,omitempty"`
+
+	// Type is the type of the stage. Options available are `constant`, `ramp`, `step`, `spike` and `sine`.
+	Type StageType `yaml:"type"`
+
+	// Duration is the duration of the stage.
+	Duration time.Duration `yaml:"duration"`
+
+	// Rate is the rate of the `constant` stage, the base rate of the `spike` stage and the mean rate of the `sine` stage.
+	Rate float64 `yaml:"rate,omitempty"`
+
+	// From is the rate at the beginning of the `ramp` and `step` stages.
+	From float64 `yaml:"from,omitempty"`
+
+	// To is the rate at the end of the `ramp` and `step` stages.
+	To float64 `yaml:"to,omitempty"`
+
+	// Steps is the number of the rate levels of the `step` stage, and each level lasts `duration / steps`.
+	Steps int `yaml:"steps,omitempty"`
+
+	// PeakRate is the rate during the spike of the `spike` stage.
+	PeakRate float64 `yaml:"peakRate,omitempty"`
+
+	// SpikeDuration is the duration of the spike of the `spike` stage. The spike is in the middle of the stage.
+	SpikeDuration time.Duration `yaml:"spikeDuration,omitempty"`
+
+	// Amplitude is the amplitude of the rate of the `sine` stage. It can't be greater than `rate`.
+	Amplitude float64 `yaml:"amplitude,omitempty"`
+
+	// Period is the period of the `sine` stage. For example, `24h` for a diurnal pattern.
+	Period time.Duration `yaml:"period,omitempty"`
+}
+
+// StageType is the type of the stage of the load profile.
+type StageType string
+
+const (
+	// StageTypeConstant keeps the rate unchanged.
+	StageTypeConstant StageType = "constant"
+
+	// StageTypeRamp changes the rate linearly from `from` to `to`.
+	StageTypeRamp StageType = "ramp"
+
+	// StageTypeStep changes the rate from `from` to `to` in the equal steps.
+	StageTypeStep StageType = "step"
+
+	// StageTypeSpike raises the rate from `rate` to `peakRate` for a short time.
+	StageTypeSpike StageType = "spike"
+
+	// StageTypeSine changes the rate periodically around `rate`.
+	StageTypeSine StageType = "sine"
+)
+
+// RetryConfig is the retry policy of the failed requests, which is similar to the exporters of the OpenTelemetry Collector.
+// The HTTP requests are retried by the status code, and the gRPC requests are retried by the equivalent status code,
+// that is, `Unavailable` for 503 and `ResourceExhausted` for 429.
+type RetryConfig struct {
+	// MaxAttempts is the max number of the attempts of a request, including the first one. Default is `3`.
+	MaxAttempts int `yaml:"maxAttempts,omitempty"`
+
+	// InitialBackoff is the backoff before the first retry. Default is `100ms`.
+	InitialBackoff time.Duration `yaml:"initialBackoff,omitempty"`
+
+	// MaxBackoff is the max backoff between the retries, and the delay requested by the target is also capped by it. Default is `5s`.
+	MaxBackoff time.Duration `yaml:"maxBackoff,omitempty"`
+
+	// Multiplier is the multiplier of the backoff after each retry. Default is `2`.
+	Multiplier float64 `yaml:"multiplier,omitempty"`
+
+	// Jitter is the random factor of the backoff, for example, `0.2` randomizes the backoff in [0.8, 1.2] times. Default is `0.2`.
+	Jitter float64 `yaml:"jitter,omitempty"`
+
+	// RetryableStatusCodes are the status codes to retry. Default is `[429, 502, 503, 504]`.
+	RetryableStatusCodes []int `yaml:"retryableStatusCodes,omitempty"`
+
+	// RespectRetryAfter waits for the `Retry-After` header of the response instead of the backoff if it's set, up to `maxBackoff`. Default is `true`.
+	RespectRetryAfter *bool `yaml:"respectRetryAfter,omitempty"`
+}
+
+// PoolConfig is the configuration of the pool of the pre-generated payloads.
+type PoolConfig struct {
+	// Size is the number of the pre-generated payloads. Default is `100`.
+	Size int `yaml:"size,omitempty"`
+
+	// Timestamps is how the timestamps of the pooled payloads are updated. Options available are `keep` and `shift`. Default is `keep`.
+	// `keep` sends the payloads as they are generated, and the payloads of the HTTP targets are also encoded and compressed ahead, which has the lowest cost of the client.
+	// `shift` shifts the timestamps of the log records and the samples by the time since they are generated, and the payload is encoded and compressed for each request.
+	// The rendered lines are not changed, so `shift` is only supported by the `otlp`, `loki` and `remoteWrite` protocols and the forward target, which encode the timestamps of the records,
+	// and it's required by the metrics, otherwise the same samples are sent again and rejected as the duplicates by the remote-write target.
+	// The counters restart from the pooled values in each cycle of the pool, which the target sees as the counter resets.
+	Timestamps PoolTimestamps `yaml:"timestamps,omitempty"`
+
+	// Storage is where the pooled payloads are stored. Options available are `memory` and `file`. Default is `memory`.
+	// `file` spills the encoded and compressed payloads to a temporary file to save the memory of a large pool, which requires the HTTP target and the `keep` timestamps.
+	Storage PoolStorage `yaml:"storage,omitempty"`
+
+	// Dir is the directory of the temporary file of the `file` storage. Default is the temporary directory of the system.
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// PoolTimestamps is how the timestamps of the pooled payloads are updated.
+type PoolTimestamps string
+
+const (
+	// PoolTimestampsKeep keeps the timestamps when the payload is generated.
+	PoolTimestampsKeep PoolTimestamps = "keep"
+
+	// PoolTimestampsShift shifts the timestamps to the time when the payload is sent.
+	PoolTimestampsShift PoolTimestamps = "shift"
+)
+
+// PoolStorage is where the pooled payloads are stored.
+type PoolStorage string
+
+const (
+	// PoolStorageMemory stores the payloads in memory.
+	PoolStorageMemory PoolStorage = "memory"
+
+	// PoolStorageFile stores the payloads in a temporary file.
+	PoolStorageFile PoolStorage = "file"
+)
+
+// Arrival is the distribution of the intended start times of the requests.
+type Arrival string
+
+const (
+	// ArrivalConstant makes the requests at the fixed interval.
+	ArrivalConstant Arrival = "constant"
+
+	// ArrivalPoisson makes the requests by the Poisson process.
+	ArrivalPoisson Arrival = "poisson"
+)
+
 // HTTPConfig is the configuration for the HTTP requests.
 type HTTPConfig struct {
+	// Scheme is the scheme of the target URL. Options available are `http` and `https`. Default is `http`, and it's `https` if the TLS is set.
+	Scheme string `yaml:"scheme,omitempty"`
+
 	// Host is the host of the target. For example: `127.0.0.1`.
 	Host string `yaml:"host"`
 
@@ -47,51 +343,688 @@ type HTTPConfig struct {
 	Port int `yaml:"port"`
 
 	// URI is the URI of the target. For example: `/api/v1/users`.
-	// You can use the template syntax to generate the URI. For example: `/api/v1/users?db={{ .DB }}`. The token should be defined in the generation stage.
+	// You can use the template syntax to generate the URI of each request by the tokens. For example: `/api/v1/users?db={{ .db }}`.
+	// The values can be escaped by `urlquery`, for example, `{{ .db | urlquery }}`.
 	URI string `yaml:"uri"`
 
 	// Method is the HTTP method to use. For example: `POST`.
 	Method string `yaml:"method"`
 
 	// Headers is the multiple key-value pairs of the HTTP headers.
+	// The values can use the template syntax like the URI, for example, `X-Scope-OrgID: "{{ .tenant }}"`.
 	Headers map[string]string `yaml:"headers,omitempty"`
 
+	// Tokens are the request-level tokens used by the templates of the URI and the headers.
+	// The values are generated for each request, so a run can fan out across many tenants, databases or tables.
+	Tokens []*RequestTokenConfig `yaml:"tokens,omitempty"`
+
 	// Compression is the compression algorithm to use.
-	// If not set, the payload will not be compressed. Option available is `gzip`.
+	// If not set, the payload will not be compressed. Options available are `gzip`, `zstd`, `snappy`, `snappyFramed`, `lz4` and `deflate`.
 	Compression string `yaml:"compression,omitempty"`
 
+	// CompressionLevel is the level of the compression. If not set, the default level of the compression is used.
+	// It's from 1 to 9 for `gzip`, `lz4` and `deflate`, and from 1 to 22 for `zstd`. It can't be set for `snappy` and `snappyFramed`.
+	CompressionLevel int `yaml:"compressionLevel,omitempty"`
+
 	// ResponseHeaderTimeout is the timeout for the response header. Default is `10s`.
 	ResponseHeaderTimeout time.Duration `yaml:"responseHeaderTimeout,omitempty"`
+
+	// Transport is the tuning of the connections and the timeouts. The connections are shared by the workers by default.
+	Transport *HTTPTransportConfig `yaml:"transport,omitempty"`
+
+	// TLS is the TLS configuration for the `https` scheme. If not set, the system CA pool is used to verify the target.
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+
+	// Auth is the authentication of the requests. The credentials can be read from the environment variables or the files instead of the config file.
+	Auth *AuthConfig `yaml:"auth,omitempty"`
+
+	// Response is the rules to decide whether the response of the target is successful.
+	// If not set, the protocol decides it, for example, the bulk response of Elasticsearch is parsed, and the `2xx` status codes are accepted by default.
+	Response *ResponseConfig `yaml:"response,omitempty"`
+}
+
+// HTTPTransportConfig is the tuning of the HTTP connections and the timeouts.
+type HTTPTransportConfig struct {
+	// MaxIdleConns is the max number of the idle connections to keep for the reuse. Default is `100`.
+	MaxIdleConns int `yaml:"maxIdleConns,omitempty"`
+
+	// MaxIdleConnsPerHost is the max number of the idle connections to keep for the target. Default is `100`.
+	// The connections beyond it are closed after the requests, so it should be no less than the workers to avoid the connection churn.
+	MaxIdleConnsPerHost int `yaml:"maxIdleConnsPerHost,omitempty"`
+
+	// MaxConnsPerHost is the max number of the connections to the target, including the ones in use. If not set, it's unlimited.
+	MaxConnsPerHost int `yaml:"maxConnsPerHost,omitempty"`
+
+	// IdleConnTimeout is the time that an idle connection is kept before it's closed. Default is `90s`.
+	IdleConnTimeout time.Duration `yaml:"idleConnTimeout,omitempty"`
+
+	// DisableKeepAlives makes a new connection for each request, which is useful to benchmark the cost of the connection setup.
+	DisableKeepAlives bool `yaml:"disableKeepAlives,omitempty"`
+
+	// HTTP2 sends the requests by HTTP/2, which is h2c(HTTP/2 with the prior knowledge) for the `http` scheme and HTTP/2 over TLS for the `https` scheme.
+	// If not set, HTTP/1.1 is used.
+	HTTP2 bool `yaml:"http2,omitempty"`
+
+	// ConnectionPerWorker gives each worker its own connections instead of sharing the connections by all the workers.
+	ConnectionPerWorker bool `yaml:"connectionPerWorker,omitempty"`
+
+	// DialTimeout is the timeout to establish the TCP connection. Default is `30s`.
+	DialTimeout time.Duration `yaml:"dialTimeout,omitempty"`
+
+	// TLSHandshakeTimeout is the timeout of the TLS handshake. Default is `10s`.
+	TLSHandshakeTimeout time.Duration `yaml:"tlsHandshakeTimeout,omitempty"`
+
+	// Timeout is the overall timeout of a request, including the connection setup, the redirects and reading the response body. Default is `30s`.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// RequestTokenConfig is a request-level token. The value is generated by the faker like the tokens of the logs if it's not set.
+type RequestTokenConfig struct {
+	// Name is the name of the token. You can use this name in the templates of the URI and the headers, for example, `{{ .tenant }}`.
+	Name string `yaml:"name"`
+
+	// Type is the type of the token. Default is `string`.
+	Type common.ElementType `yaml:"type,omitempty"`
+
+	// FakeConfig is the configuration for how to generate the fake data.
+	// For example, the `words` kind with `fixedWords` picks a tenant from the list, and the `number` kind with `prefix` makes `tenant-1` to `tenant-100`.
+	FakeConfig *faker.FakeConfig `yaml:"fake,omitempty"`
+
+	// Value is the fixed value of the token. If this is set, the value will not be generated by the faker.
+	Value any `yaml:"value,omitempty"`
+}
+
+// ResponseConfig is the rules to decide whether the HTTP response of the target is successful.
+type ResponseConfig struct {
+	// StatusCodes are the accepted status codes. Each one can be a status code like `200`, a class like `2xx` or a range like `200-299`.
+	// If set, it replaces the check of the status code and the body by the protocol. Default is the check of the protocol.
+	StatusCodes []string `yaml:"statusCodes,omitempty"`
+
+	// Assertions are the assertions of the response body. The request fails if any of them fails.
+	Assertions []*AssertionConfig `yaml:"assertions,omitempty"`
+
+	// RecordsAccepted is the JSON path of the number of the accepted records in the response body, for example, `accepted`.
+	// If it's less than the records of the request, the rest are counted as the rejected records.
+	RecordsAccepted string `yaml:"recordsAccepted,omitempty"`
+}
+
+// AssertionConfig is an assertion of the response body. One of `equals` and `regex` must be set.
+type AssertionConfig struct {
+	// JSONPath is the path of the value in the JSON body, for example, `errors` or `items[0].status`. If not set, the whole body is asserted.
+	JSONPath string `yaml:"jsonPath,omitempty"`
+
+	// Equals is the expected value. The string is compared without the quotes and the other values are compared by their JSON, for example, `false`.
+	Equals string `yaml:"equals,omitempty"`
+
+	// Regex is the regular expression that the value must match.
+	Regex string `yaml:"regex,omitempty"`
+}
+
+// GRPCConfig is the configuration for the OTLP gRPC requests.
+type GRPCConfig struct {
+	// Host is the host of the target. For example: `127.0.0.1`.
+	Host string `yaml:"host"`
+
+	// Port is the port of the target. Default is `4317`.
+	Port int `yaml:"port,omitempty"`
+
+	// Headers is the multiple key-value pairs of the gRPC metadata.
+	Headers map[string]string `yaml:"headers,omitempty"`
+
+	// Compression is the compression algorithm to use.
+	// If not set, the payload will not be compressed. Option available is `gzip`.
+	Compression string `yaml:"compression,omitempty"`
+
+	// Connections is the number of the gRPC connections in the pool. The workers share the connections in round-robin. Default is `1`.
+	Connections int `yaml:"connections,omitempty"`
+
+	// Timeout is the timeout for each export request. Default is `10s`.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+
+	// TLS is the TLS configuration of the connections. If not set, the connections are in plaintext.
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+
+	// Auth is the authentication of the export requests, which is sent as the gRPC metadata.
+	Auth *AuthConfig `yaml:"auth,omitempty"`
+}
+
+// SyslogConfig is the configuration for sending the logs to the syslog receiver. Each generated log is sent as a syslog message.
+// It's recommended to use the `rfc3164` or `rfc5424` log format.
+type SyslogConfig struct {
+	// Host is the host of the target. For example: `127.0.0.1`.
+	Host string `yaml:"host"`
+
+	// Port is the port of the target. Default is `514`, and it's `6514` for the `tls` transport.
+	Port int `yaml:"port,omitempty"`
+
+	// Transport is the transport of the syslog messages. Options available are `udp`, `tcp` and `tls`. Default is `tcp`.
+	Transport string `yaml:"transport,omitempty"`
+
+	// Framing is the framing of the syslog messages over the `tcp` and `tls` transport. It's ignored for the `udp` transport since each message is a datagram.
+	// Options available are `octet-counting`(RFC 5425/6587) and `non-transparent`(newline delimited, RFC 6587). Default is `octet-counting`.
+	Framing string `yaml:"framing,omitempty"`
+
+	// TLS is the TLS configuration for the `tls` transport.
+	TLS *TLSConfig `yaml:"tls,omitempty"`
+
+	// Timeout is the timeout for dialing and writing the messages. Default is `10s`.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+const (
+	// SyslogFramingOctetCounting prefixes each message with its length, for example, `42 <34>1 ...`.
+	SyslogFramingOctetCounting = "octet-counting"
+
+	// SyslogFramingNonTransparent terminates each message with a newline.
+	SyslogFramingNonTransparent = "non-transparent"
+)
+
+// ForwardConfig is the configuration for sending the logs to the Fluentd/Fluent Bit by the Forward protocol over TCP.
+// The entries of the messages are built from the generated tokens, and the key of each token is the display name of the token if it's set, otherwise it's the name of the token.
+type ForwardConfig struct {
+	// Host is the host of the target. For example: `127.0.0.1`.
+	Host string `yaml:"host"`
+
+	// Port is the port of the target. Default is `24224`.
+	Port int `yaml:"port,omitempty"`
+
+	// Mode is the mode of the Forward protocol. Options available are `message`, `forward`, `packedForward` and `compressedPackedForward`. Default is `forward`.
+	// The `message` mode sends each log as a message, and the other modes send all the logs of a request in a message.
+	Mode ForwardMode `yaml:"mode,omitempty"`
+
+	// Tag is the tag of the messages. Default is `o11ybench`.
+	Tag string `yaml:"tag,omitempty"`
+
+	// TagToken is the name of the token whose value will be appended to the tag, for example, `o11ybench.<value>`.
+	// The logs with different tags in a request are sent in the separate messages.
+	TagToken string `yaml:"tagToken,omitempty"`
+
+	// LineKey is the key of the rendered log line in the entry. If not set, the rendered log line will not be included.
+	LineKey string `yaml:"lineKey,omitempty"`
+
+	// RequireAck is whether to wait for the acknowledgement of each message from the target.
+	RequireAck bool `yaml:"requireAck,omitempty"`
+
+	// Timeout is the timeout for dialing, writing the messages and waiting for the acknowledgement. Default is `10s`.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// ForwardMode is the mode of the Forward protocol.
+type ForwardMode string
+
+const (
+	// ForwardModeMessage sends each log as `[tag, time, record, option]`.
+	ForwardModeMessage ForwardMode = "message"
+
+	// ForwardModeForward sends the logs as `[tag, [[time, record], ...], option]`.
+	ForwardModeForward ForwardMode = "forward"
+
+	// ForwardModePackedForward sends the logs as `[tag, <the binary of the msgpack encoded entries>, option]`.
+	ForwardModePackedForward ForwardMode = "packedForward"
+
+	// ForwardModeCompressedPackedForward is the same as the `packedForward` but the entries are compressed by gzip.
+	ForwardModeCompressedPackedForward ForwardMode = "compressedPackedForward"
+)
+
+// FileConfig is the configuration for writing the logs into the files on disk. Each generated log is written as a line.
+type FileConfig struct {
+	// Path is the path of the log file. For example: `/var/log/o11ybench/app.log`.
+	// If there are multiple files, the index of the file will be added to the file name, for example, `app-0.log`, `app-1.log`.
+	Path string `yaml:"path"`
+
+	// Files is the number of files written in parallel. The requests are spread across the files in the round-robin order. Default is `1`.
+	Files int `yaml:"files,omitempty"`
+
+	// Rotation is the rotation configuration of the files. If not set, the files will not be rotated.
+	Rotation *FileRotationConfig `yaml:"rotation,omitempty"`
+
+	// Fsync is when to fsync the files. Options available are `never`, `always` and `interval`. Default is `never`.
+	// `always` fsyncs the file after each write, and `interval` fsyncs all the files every `fsyncInterval`.
+	Fsync string `yaml:"fsync,omitempty"`
+
+	// FsyncInterval is the interval of fsync when `fsync` is `interval`. Default is `1s`.
+	FsyncInterval time.Duration `yaml:"fsyncInterval,omitempty"`
+}
+
+// FileRotationConfig is the rotation configuration of the log files. The file is rotated when it reaches the max size or the interval, whichever comes first.
+// The rotated files are named with the suffix of the backup index, for example, `app.log.1` is the newest one.
+type FileRotationConfig struct {
+	// MaxSize is the max size of the file before it's rotated. For example: `100mb`.
+	MaxSize string `yaml:"maxSize,omitempty"`
+
+	// Interval is the interval to rotate the file. For example: `1m`.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Strategy is how to rotate the file. Options available are `rename` and `copytruncate`. Default is `rename`.
+	// `rename` renames the file and creates a new one, and `copytruncate` copies the file to the backup and truncates it in place.
+	Strategy string `yaml:"strategy,omitempty"`
+
+	// MaxBackups is the max number of the rotated files to keep. Default is `5`.
+	MaxBackups int `yaml:"maxBackups,omitempty"`
+}
+
+const (
+	// FileRotationRename renames the file to the backup and creates a new file.
+	FileRotationRename = "rename"
+
+	// FileRotationCopyTruncate copies the file to the backup and truncates the file.
+	FileRotationCopyTruncate = "copytruncate"
+)
+
+const (
+	// FileFsyncNever never fsyncs the files and leaves it to the OS.
+	FileFsyncNever = "never"
+
+	// FileFsyncAlways fsyncs the file after each write.
+	FileFsyncAlways = "always"
+
+	// FileFsyncInterval fsyncs all the files periodically.
+	FileFsyncInterval = "interval"
+)
+
+// TLSConfig is the TLS configuration of the connections to the target.
+type TLSConfig struct {
+	// CAFile is the path of the CA bundle to verify the certificate of the target. If not set, the system CA pool will be used.
+	CAFile string `yaml:"caFile,omitempty"`
+
+	// ServerName is used to verify the hostname of the certificate of the target. If not set, the host of the target will be used.
+	ServerName string `yaml:"serverName,omitempty"`
+
+	// InsecureSkipVerify skips the verification of the certificate of the target.
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify,omitempty"`
+
+	// CertFile and KeyFile are the paths of the client certificate and its private key for the mutual TLS. They must be set together.
+	CertFile string `yaml:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile,omitempty"`
+}
+
+// AuthConfig is the authentication of the requests. Only one of basic, bearer and apiKey can be set.
+type AuthConfig struct {
+	// Basic is the HTTP basic authentication.
+	Basic *BasicAuthConfig `yaml:"basic,omitempty"`
+
+	// Bearer is the bearer token in the `Authorization` header.
+	Bearer *BearerAuthConfig `yaml:"bearer,omitempty"`
+
+	// APIKey is the API key in a custom header.
+	APIKey *APIKeyAuthConfig `yaml:"apiKey,omitempty"`
+
+	// RefreshInterval is the interval to read the credentials again, for example, the token file that is rotated during a long soak run.
+	// If not set, the credentials are only read once at the start.
+	RefreshInterval time.Duration `yaml:"refreshInterval,omitempty"`
+}
+
+// BasicAuthConfig is the HTTP basic authentication.
+type BasicAuthConfig struct {
+	Username string        `yaml:"username"`
+	Password *SecretConfig `yaml:"password"`
+}
+
+// BearerAuthConfig is the bearer token authentication.
+type BearerAuthConfig struct {
+	Token *SecretConfig `yaml:"token"`
+}
+
+// APIKeyAuthConfig is the API key authentication.
+type APIKeyAuthConfig struct {
+	// Header is the header of the API key. Default is `X-API-Key`.
+	Header string `yaml:"header,omitempty"`
+
+	Key *SecretConfig `yaml:"key"`
+}
+
+// SecretConfig is a credential. Only one of value, env and file can be set.
+type SecretConfig struct {
+	// Value is the literal value of the credential. It's not recommended since the config file may be committed.
+	Value string `yaml:"value,omitempty"`
+
+	// Env is the environment variable of the credential.
+	Env string `yaml:"env,omitempty"`
+
+	// File is the path of the file of the credential. The leading and trailing whitespaces are trimmed.
+	File string `yaml:"file,omitempty"`
 }
 
 // Defaults returns the default loader config.
 func (c Config) Defaults() *Config {
-	return &Config{
-		Workers: 2,
-		HTTP:    *HTTPConfig{}.defaults(),
+	defaults := &Config{
+		Workers:  2,
+		Arrival:  ArrivalConstant,
+		Protocol: ProtocolRaw,
+	}
+
+	if c.GRPC != nil {
+		defaults.Protocol = ProtocolOTLP
+		defaults.GRPC = GRPCConfig{}.defaults()
+	}
+
+	if c.Syslog != nil {
+		defaults.Syslog = SyslogConfig{}.defaults()
+		if c.Syslog.Transport == "tls" {
+			defaults.Syslog.Port = 6514
+		}
+	}
+
+	if c.Forward != nil {
+		defaults.Forward = ForwardConfig{}.defaults()
+	}
+
+	if c.File != nil {
+		defaults.File = FileConfig{}.defaults()
+		if c.File.Rotation != nil {
+			defaults.File.Rotation = FileRotationConfig{}.defaults()
+		}
+	}
+
+	if c.Protocol == ProtocolOTLP || c.GRPC != nil {
+		defaults.OTLP = OTLPConfig{}.defaults()
+	}
+
+	if c.Protocol == ProtocolLoki {
+		defaults.Loki = LokiConfig{}.defaults()
 	}
+
+	if c.Protocol == ProtocolElasticsearch {
+		defaults.Elasticsearch = ElasticsearchConfig{}.defaults()
+	}
+
+	if c.Metrics != nil {
+		defaults.Metrics = &MetricsGeneratorConfig{SamplesPerSeries: 1}
+	}
+
+	if c.Retry != nil {
+		defaults.Retry = RetryConfig{}.defaults()
+	}
+
+	if c.Pool != nil {
+		defaults.Pool = PoolConfig{}.defaults()
+	}
+
+	if c.HTTP != nil {
+		defaults.HTTP = HTTPConfig{}.defaults()
+		if c.HTTP.TLS != nil {
+			defaults.HTTP.Scheme = "https"
+		}
+		switch c.Protocol {
+		case ProtocolOTLP:
+			defaults.HTTP.URI = "/v1/logs"
+			defaults.HTTP.Method = http.MethodPost
+		case ProtocolLoki:
+			defaults.HTTP.URI = "/loki/api/v1/push"
+			defaults.HTTP.Method = http.MethodPost
+		case ProtocolElasticsearch:
+			defaults.HTTP.URI = "/_bulk"
+			defaults.HTTP.Method = http.MethodPost
+		case ProtocolRemoteWrite:
+			defaults.HTTP.URI = "/api/v1/write"
+			defaults.HTTP.Method = http.MethodPost
+		}
+	}
+
+	return defaults
+}
+
+// recordsPerRequest returns the number of the records in each request. The record is a log for the logs or a sample for the metrics.
+func (c *Config) recordsPerRequest() int64 {
+	if c.Metrics != nil {
+		return int64(c.Metrics.SeriesPerRequest * c.Metrics.SamplesPerSeries)
+	}
+
+	if c.Logs != nil {
+		return int64(c.Logs.RecordsPerRequest)
+	}
+
+	return 0
+}
+
+// shiftableTimestamps returns whether the pooled timestamps can be shifted for the target,
+// that is, the timestamps are encoded from the log records or the samples instead of the rendered lines.
+func (c *Config) shiftableTimestamps() bool {
+	switch {
+	case c.Forward != nil:
+		return true
+	case c.Syslog != nil, c.File != nil:
+		return false
+	}
+
+	return c.Protocol == ProtocolOTLP || c.Protocol == ProtocolLoki || c.Protocol == ProtocolRemoteWrite
 }
 
 // Validate validates the configuration.
 func (c *Config) Validate() error {
-	if c.Rate <= 0 {
+	if c.Profile != nil {
+		if c.Duration > 0 {
+			return fmt.Errorf("duration can't be set with the profile")
+		}
+
+		if err := c.Profile.validate(); err != nil {
+			return fmt.Errorf("invalid profile config: %w", err)
+		}
+	} else if c.Rate <= 0 {
 		return fmt.Errorf("rate must be greater than 0")
 	}
 
+	if c.Retry != nil {
+		if err := c.Retry.validate(); err != nil {
+			return fmt.Errorf("invalid retry config: %w", err)
+		}
+	}
+
+	if c.Pool != nil {
+		if err := c.Pool.validate(); err != nil {
+			return fmt.Errorf("invalid pool config: %w", err)
+		}
+
+		if c.Pool.Storage == PoolStorageFile && (c.HTTP == nil || c.GRPC != nil || c.Syslog != nil || c.Forward != nil || c.File != nil) {
+			return fmt.Errorf("the file storage of the pool requires the http target")
+		}
+
+		if c.Pool.Timestamps == PoolTimestampsShift && !c.shiftableTimestamps() {
+			return fmt.Errorf("the '%s' timestamps of the pool are not supported by the '%s' protocol, the timestamps in the rendered lines can't be shifted", PoolTimestampsShift, c.Protocol)
+		}
+
+		if c.Metrics != nil && c.Pool.Timestamps != PoolTimestampsShift {
+			return fmt.Errorf("the pool of the metrics requires the '%s' timestamps, otherwise the same samples are rejected as the duplicates", PoolTimestampsShift)
+		}
+	}
+
 	if c.Workers <= 0 {
 		return fmt.Errorf("workers must be greater than 0")
 	}
 
-	if err := c.HTTP.validate(); err != nil {
-		return err
+	if c.Arrival != ArrivalConstant && c.Arrival != ArrivalPoisson {
+		return fmt.Errorf("invalid arrival: '%s'", c.Arrival)
+	}
+
+	switch c.Protocol {
+	case ProtocolRaw, ProtocolOTLP:
+	case ProtocolLoki:
+		if c.Loki == nil {
+			return fmt.Errorf("loki config is required for the loki protocol")
+		}
+
+		if err := c.Loki.validate(); err != nil {
+			return fmt.Errorf("invalid loki config: %w", err)
+		}
+
+		if c.Loki.Format == LokiFormatProtobuf && c.HTTP != nil && c.HTTP.Compression != "" {
+			return fmt.Errorf("the protobuf format of the loki protocol is always compressed by snappy, the compression can't be set")
+		}
+	case ProtocolRemoteWrite:
+		if c.Metrics == nil {
+			return fmt.Errorf("metrics generator config for loader is required for the remoteWrite protocol")
+		}
+
+		if c.HTTP != nil && c.HTTP.Compression != "" {
+			return fmt.Errorf("the remoteWrite protocol is always compressed by snappy, the compression can't be set")
+		}
+	case ProtocolElasticsearch:
+		if c.Elasticsearch == nil {
+			return fmt.Errorf("elasticsearch config is required for the elasticsearch protocol")
+		}
+
+		if err := c.Elasticsearch.validate(); err != nil {
+			return fmt.Errorf("invalid elasticsearch config: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid protocol: '%s'", c.Protocol)
+	}
+
+	targets := 0
+	for _, set := range []bool{c.HTTP != nil, c.GRPC != nil, c.Syslog != nil, c.Forward != nil, c.File != nil} {
+		if set {
+			targets++
+		}
+	}
+
+	if targets == 0 {
+		return fmt.Errorf("http, grpc, syslog, forward or file config for loader is required")
+	}
+
+	if targets > 1 {
+		return fmt.Errorf("only one of http, grpc, syslog, forward and file can be set")
+	}
+
+	if c.HTTP != nil {
+		if err := c.HTTP.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.GRPC != nil {
+		if c.Protocol != ProtocolOTLP {
+			return fmt.Errorf("grpc only supports the otlp protocol")
+		}
+
+		if err := c.GRPC.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Syslog != nil {
+		if c.Protocol != ProtocolRaw {
+			return fmt.Errorf("syslog only supports the raw protocol")
+		}
+
+		if err := c.Syslog.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Forward != nil {
+		if c.Protocol != ProtocolRaw {
+			return fmt.Errorf("forward only supports the raw protocol")
+		}
+
+		if err := c.Forward.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.File != nil {
+		if c.Protocol != ProtocolRaw {
+			return fmt.Errorf("file only supports the raw protocol")
+		}
+
+		if c.Logs == nil {
+			return fmt.Errorf("file only supports the logs")
+		}
+
+		if err := c.File.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Logs == nil && c.Metrics == nil {
+		return fmt.Errorf("logs or metrics generator config for loader is required")
+	}
+
+	if c.Logs != nil && c.Metrics != nil {
+		return fmt.Errorf("only one of logs and metrics generator config can be set")
+	}
+
+	if c.Logs != nil {
+		if err := c.Logs.validate(); err != nil {
+			return fmt.Errorf("invalid logs generator config: %w", err)
+		}
+	}
+
+	if c.Metrics != nil {
+		if err := c.Metrics.validate(); err != nil {
+			return fmt.Errorf("invalid metrics generator config: %w", err)
+		}
+
+		// The metrics are sent in the text format by the raw protocol, by the remote-write protocol or by the otlp protocol over http or grpc.
+		if c.HTTP == nil && c.GRPC == nil {
+			return fmt.Errorf("the metrics generator only supports the http and grpc targets")
+		}
+
+		if c.Protocol != ProtocolRaw && c.Protocol != ProtocolRemoteWrite && c.Protocol != ProtocolOTLP {
+			return fmt.Errorf("the metrics generator doesn't support the '%s' protocol, options available are '%s', '%s' and '%s'", c.Protocol, ProtocolRaw, ProtocolRemoteWrite, ProtocolOTLP)
+		}
+	}
+
+	return nil
+}
+
+func (c *ProfileConfig) validate() error {
+	if len(c.Stages) == 0 {
+		return fmt.Errorf("stages are required")
 	}
 
-	if c.Logs == nil {
-		return fmt.Errorf("logs generator config for loader is required")
+	for i, stage := range c.Stages {
+		if err := stage.validate(); err != nil {
+			return fmt.Errorf("invalid stage %d: %w", i, err)
+		}
 	}
 
-	if err := c.Logs.validate(); err != nil {
-		return fmt.Errorf("invalid logs generator config: %w", err)
+	return nil
+}
+
+func (c *StageConfig) validate() error {
+	if c.Duration <= 0 {
+		return fmt.Errorf("duration must be greater than 0")
+	}
+
+	switch c.Type {
+	case StageTypeConstant:
+		if c.Rate <= 0 {
+			return fmt.Errorf("rate must be greater than 0")
+		}
+	case StageTypeRamp:
+		if c.From < 0 || c.To < 0 || c.From+c.To == 0 {
+			return fmt.Errorf("from and to must not be negative, and one of them must be greater than 0")
+		}
+	case StageTypeStep:
+		if c.From < 0 || c.To < 0 || c.From+c.To == 0 {
+			return fmt.Errorf("from and to must not be negative, and one of them must be greater than 0")
+		}
+
+		if c.Steps < 2 {
+			return fmt.Errorf("steps must be at least 2")
+		}
+	case StageTypeSpike:
+		if c.Rate < 0 || c.PeakRate <= c.Rate {
+			return fmt.Errorf("peakRate must be greater than rate")
+		}
+
+		if c.SpikeDuration <= 0 || c.SpikeDuration > c.Duration {
+			return fmt.Errorf("spikeDuration must be greater than 0 and not greater than duration")
+		}
+	case StageTypeSine:
+		if c.Rate <= 0 {
+			return fmt.Errorf("rate must be greater than 0")
+		}
+
+		if c.Amplitude < 0 || c.Amplitude > c.Rate {
+			return fmt.Errorf("amplitude must be between 0 and rate")
+		}
+
+		if c.Period <= 0 {
+			return fmt.Errorf("period must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid type: '%s'", c.Type)
 	}
 
 	return nil
@@ -114,15 +1047,448 @@ func (c *HTTPConfig) validate() error {
 		return fmt.Errorf("method is required")
 	}
 
-	if c.Compression != "" && c.Compression != "gzip" {
-		return fmt.Errorf("only gzip compression is supported")
+	if err := validateCompression(c.Compression, c.CompressionLevel); err != nil {
+		return err
+	}
+
+	scheme := c.scheme()
+	if scheme != "http" && scheme != "https" {
+		return fmt.Errorf("invalid scheme: '%s'", scheme)
+	}
+
+	if c.TLS != nil {
+		if scheme != "https" {
+			return fmt.Errorf("tls can't be set with the '%s' scheme", scheme)
+		}
+
+		if err := c.TLS.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Response != nil {
+		if _, err := newResponseValidator(c.Response); err != nil {
+			return fmt.Errorf("invalid response config: %w", err)
+		}
+	}
+
+	if _, err := newRequestTemplates(c); err != nil {
+		return err
+	}
+
+	if c.Transport != nil {
+		if err := c.Transport.validate(); err != nil {
+			return fmt.Errorf("invalid transport config: %w", err)
+		}
 	}
 
 	return nil
 }
 
+// scheme returns the scheme of the target URL. It's `https` if the TLS is set and `http` otherwise when the scheme is not set.
+func (c *HTTPConfig) scheme() string {
+	if c.Scheme != "" {
+		return c.Scheme
+	}
+
+	if c.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// transport returns the transport config. The defaults are used if it's not set.
+func (c *HTTPConfig) transport() *HTTPTransportConfig {
+	if c.Transport == nil {
+		return HTTPTransportConfig{}.defaults()
+	}
+
+	return c.Transport
+}
+
 func (c HTTPConfig) defaults() *HTTPConfig {
 	return &HTTPConfig{
+		Scheme:                "http",
 		ResponseHeaderTimeout: 10 * time.Second,
+		Transport:             HTTPTransportConfig{}.defaults(),
+	}
+}
+
+func (c *HTTPTransportConfig) validate() error {
+	if c.MaxIdleConns < 0 || c.MaxIdleConnsPerHost < 0 || c.MaxConnsPerHost < 0 {
+		return fmt.Errorf("the max number of the connections must be greater than or equal to 0")
+	}
+
+	if c.IdleConnTimeout < 0 || c.DialTimeout < 0 || c.TLSHandshakeTimeout < 0 || c.Timeout < 0 {
+		return fmt.Errorf("the timeouts must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c HTTPTransportConfig) defaults() *HTTPTransportConfig {
+	return &HTTPTransportConfig{
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		DialTimeout:         30 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		Timeout:             30 * time.Second,
+	}
+}
+
+func (c *GRPCConfig) validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+
+	if c.Port == 0 {
+		return fmt.Errorf("port is required")
+	}
+
+	if c.Compression != "" && c.Compression != "gzip" {
+		return fmt.Errorf("only gzip compression is supported")
+	}
+
+	if c.Connections <= 0 {
+		return fmt.Errorf("connections must be greater than 0")
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Auth != nil {
+		if err := c.Auth.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c GRPCConfig) defaults() *GRPCConfig {
+	return &GRPCConfig{
+		Port:        4317,
+		Connections: 1,
+		Timeout:     10 * time.Second,
+	}
+}
+
+func (c *SyslogConfig) validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+
+	if c.Port == 0 {
+		return fmt.Errorf("port is required")
+	}
+
+	if c.Transport != "udp" && c.Transport != "tcp" && c.Transport != "tls" {
+		return fmt.Errorf("invalid transport: '%s'", c.Transport)
+	}
+
+	if c.Framing != SyslogFramingOctetCounting && c.Framing != SyslogFramingNonTransparent {
+		return fmt.Errorf("invalid framing: '%s'", c.Framing)
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *TLSConfig) validate() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return fmt.Errorf("certFile and keyFile must be set together")
+	}
+
+	return nil
+}
+
+func (c *PoolConfig) validate() error {
+	if c.Size <= 0 {
+		return fmt.Errorf("size must be greater than 0")
+	}
+
+	switch c.Timestamps {
+	case PoolTimestampsShift, PoolTimestampsKeep, "":
+	default:
+		return fmt.Errorf("invalid timestamps: '%s', options available are '%s' and '%s'", c.Timestamps, PoolTimestampsShift, PoolTimestampsKeep)
+	}
+
+	switch c.Storage {
+	case PoolStorageMemory, "":
+	case PoolStorageFile:
+		if c.Timestamps == PoolTimestampsShift {
+			return fmt.Errorf("the file storage requires the '%s' timestamps", PoolTimestampsKeep)
+		}
+	default:
+		return fmt.Errorf("invalid storage: '%s', options available are '%s' and '%s'", c.Storage, PoolStorageMemory, PoolStorageFile)
+	}
+
+	return nil
+}
+
+func (c PoolConfig) defaults() *PoolConfig {
+	return &PoolConfig{
+		Size:       100,
+		Timestamps: PoolTimestampsKeep,
+		Storage:    PoolStorageMemory,
+	}
+}
+
+func (c *RetryConfig) validate() error {
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("maxAttempts must be greater than 0")
+	}
+
+	if c.InitialBackoff <= 0 || c.MaxBackoff < c.InitialBackoff {
+		return fmt.Errorf("initialBackoff must be greater than 0 and not greater than maxBackoff")
+	}
+
+	if c.Multiplier < 1 {
+		return fmt.Errorf("multiplier must be greater than or equal to 1")
+	}
+
+	if c.Jitter < 0 || c.Jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+
+	for _, code := range c.RetryableStatusCodes {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("invalid retryable status code: '%d'", code)
+		}
+	}
+
+	return nil
+}
+
+func (c RetryConfig) defaults() *RetryConfig {
+	respectRetryAfter := true
+	return &RetryConfig{
+		MaxAttempts:          3,
+		InitialBackoff:       100 * time.Millisecond,
+		MaxBackoff:           5 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		RespectRetryAfter:    &respectRetryAfter,
+	}
+}
+
+func (c *AuthConfig) validate() error {
+	methods := 0
+	for _, set := range []bool{c.Basic != nil, c.Bearer != nil, c.APIKey != nil} {
+		if set {
+			methods++
+		}
+	}
+
+	if methods != 1 {
+		return fmt.Errorf("exactly one of basic, bearer and apiKey must be set for auth")
+	}
+
+	if c.RefreshInterval < 0 {
+		return fmt.Errorf("refreshInterval must be greater than or equal to 0")
+	}
+
+	switch {
+	case c.Basic != nil:
+		if c.Basic.Username == "" {
+			return fmt.Errorf("username is required for the basic auth")
+		}
+		return c.Basic.Password.validate("password")
+	case c.Bearer != nil:
+		return c.Bearer.Token.validate("token")
+	default:
+		return c.APIKey.Key.validate("key")
+	}
+}
+
+func (c *SecretConfig) validate(name string) error {
+	if c == nil {
+		return fmt.Errorf("%s is required for auth", name)
+	}
+
+	sources := 0
+	for _, set := range []bool{c.Value != "", c.Env != "", c.File != ""} {
+		if set {
+			sources++
+		}
+	}
+
+	if sources != 1 {
+		return fmt.Errorf("exactly one of value, env and file must be set for the %s", name)
+	}
+
+	return nil
+}
+
+func (c SyslogConfig) defaults() *SyslogConfig {
+	return &SyslogConfig{
+		Port:      514,
+		Transport: "tcp",
+		Framing:   SyslogFramingOctetCounting,
+		Timeout:   10 * time.Second,
+	}
+}
+
+func (c *ForwardConfig) validate() error {
+	if c.Host == "" {
+		return fmt.Errorf("host is required")
+	}
+
+	if c.Port == 0 {
+		return fmt.Errorf("port is required")
+	}
+
+	switch c.Mode {
+	case ForwardModeMessage, ForwardModeForward, ForwardModePackedForward, ForwardModeCompressedPackedForward:
+	default:
+		return fmt.Errorf("invalid mode: '%s'", c.Mode)
+	}
+
+	if c.Tag == "" {
+		return fmt.Errorf("tag is required")
+	}
+
+	return nil
+}
+
+func (c ForwardConfig) defaults() *ForwardConfig {
+	return &ForwardConfig{
+		Port:    24224,
+		Mode:    ForwardModeForward,
+		Tag:     "o11ybench",
+		Timeout: 10 * time.Second,
+	}
+}
+
+func (c *FileConfig) validate() error {
+	if c.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if c.Files <= 0 {
+		return fmt.Errorf("files must be greater than 0")
+	}
+
+	if c.Fsync != FileFsyncNever && c.Fsync != FileFsyncAlways && c.Fsync != FileFsyncInterval {
+		return fmt.Errorf("invalid fsync: '%s'", c.Fsync)
+	}
+
+	if c.Fsync == FileFsyncInterval && c.FsyncInterval <= 0 {
+		return fmt.Errorf("fsyncInterval must be greater than 0")
+	}
+
+	if c.Rotation != nil {
+		if err := c.Rotation.validate(); err != nil {
+			return fmt.Errorf("invalid rotation config: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (c FileConfig) defaults() *FileConfig {
+	return &FileConfig{
+		Files:         1,
+		Fsync:         FileFsyncNever,
+		FsyncInterval: time.Second,
+	}
+}
+
+func (c *FileRotationConfig) validate() error {
+	if c.MaxSize == "" && c.Interval == 0 {
+		return fmt.Errorf("maxSize or interval is required")
+	}
+
+	if c.MaxSize != "" {
+		if _, err := utils.ParseSize(c.MaxSize); err != nil {
+			return err
+		}
+	}
+
+	if c.Strategy != FileRotationRename && c.Strategy != FileRotationCopyTruncate {
+		return fmt.Errorf("invalid strategy: '%s'", c.Strategy)
+	}
+
+	if c.MaxBackups <= 0 {
+		return fmt.Errorf("maxBackups must be greater than 0")
+	}
+
+	return nil
+}
+
+func (c FileRotationConfig) defaults() *FileRotationConfig {
+	return &FileRotationConfig{
+		Strategy:   FileRotationRename,
+		MaxBackups: 5,
+	}
+}
+
+func (c OTLPConfig) defaults() *OTLPConfig {
+	return &OTLPConfig{
+		ResourceAttributes: map[string]string{
+			"service.name": "o11ybench",
+		},
+		ScopeName: "o11ybench",
+	}
+}
+
+func (c *LokiConfig) validate() error {
+	if c.Format != LokiFormatProtobuf && c.Format != LokiFormatJSON {
+		return fmt.Errorf("invalid format: '%s'", c.Format)
+	}
+
+	if len(c.Labels) == 0 && len(c.LabelTokens) == 0 {
+		return fmt.Errorf("at least one label is required")
+	}
+
+	if c.MaxLabelValues < 0 {
+		return fmt.Errorf("maxLabelValues must be greater than or equal to 0")
+	}
+
+	return nil
+}
+
+func (c LokiConfig) defaults() *LokiConfig {
+	return &LokiConfig{
+		Format: LokiFormatProtobuf,
+		Labels: map[string]string{
+			"job": "o11ybench",
+		},
+	}
+}
+
+func (c *ElasticsearchConfig) validate() error {
+	if c.Index == "" {
+		return fmt.Errorf("index is required")
+	}
+
+	if c.Action != "create" && c.Action != "index" {
+		return fmt.Errorf("invalid action: '%s'", c.Action)
+	}
+
+	return nil
+}
+
+func (c ElasticsearchConfig) defaults() *ElasticsearchConfig {
+	return &ElasticsearchConfig{
+		Index:  "o11ybench",
+		Action: "create",
 	}
 }
@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"net"
+	"sync"
+)
+
+// workerConns keeps a long-lived connection for each worker. The connection is dialed lazily by the worker's first request.
+type workerConns struct {
+	mu    sync.Mutex
+	dial  func() (net.Conn, error)
+	conns map[int]net.Conn
+}
+
+func newWorkerConns(dial func() (net.Conn, error)) *workerConns {
+	return &workerConns{dial: dial, conns: make(map[int]net.Conn)}
+}
+
+// get returns the connection of the worker. It dials a new connection if the worker doesn't have one.
+// The dial is done without the lock, so a slow dial doesn't block the other workers.
+func (c *workerConns) get(workerID int) (net.Conn, error) {
+	c.mu.Lock()
+	conn, ok := c.conns[workerID]
+	c.mu.Unlock()
+	if ok {
+		return conn, nil
+	}
+
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Only the worker itself gets its connection, but keep the published one in case it's dialed concurrently.
+	if existing, ok := c.conns[workerID]; ok {
+		conn.Close()
+		return existing, nil
+	}
+	c.conns[workerID] = conn
+
+	return conn, nil
+}
+
+// reset closes the connection of the worker. The connection may be broken, so it will be re-established in the next request.
+func (c *workerConns) reset(workerID int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if conn, ok := c.conns[workerID]; ok {
+		conn.Close()
+		delete(c.conns, workerID)
+	}
+}
+
+// close closes all the connections.
+func (c *workerConns) close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lastErr error
+	for id, conn := range c.conns {
+		if err := conn.Close(); err != nil {
+			lastErr = err
+		}
+		delete(c.conns, id)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,63 @@
+package loader
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWorkerConnsSlowDial(t *testing.T) {
+	var (
+		dials   atomic.Int64
+		release = make(chan struct{})
+	)
+
+	// The first dial is blocked until it's released, and the others return immediately.
+	conns := newWorkerConns(func() (net.Conn, error) {
+		if dials.Add(1) == 1 {
+			<-release
+		}
+		client, server := net.Pipe()
+		server.Close()
+		return client, nil
+	})
+	defer conns.close()
+
+	slow := make(chan error, 1)
+	go func() {
+		_, err := conns.get(0)
+		slow <- err
+	}()
+
+	// Wait until the slow dial is started.
+	for dials.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := conns.get(1)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("failed to get the connection: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("the other worker is blocked by the slow dial")
+	}
+
+	close(release)
+	if err := <-slow; err != nil {
+		t.Fatalf("failed to get the connection: %v", err)
+	}
+
+	first, _ := conns.get(0)
+	second, _ := conns.get(0)
+	if first != second || dials.Load() != 2 {
+		t.Fatalf("the connection of the worker is not reused, dials: '%d'", dials.Load())
+	}
+}
@@ -0,0 +1,149 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+const (
+	// ContentTypeNDJSON is the content type of the newline delimited JSON payload.
+	ContentTypeNDJSON = "application/x-ndjson"
+)
+
+// elasticsearchEncoder encodes the generated JSON logs into the Elasticsearch/OpenSearch `_bulk` request.
+type elasticsearchEncoder struct {
+	cfg *ElasticsearchConfig
+
+	// indexTemplate is the template of the index. It's nil if the index is a literal.
+	indexTemplate *template.Template
+}
+
+var (
+	_ Encoder         = &elasticsearchEncoder{}
+	_ responseChecker = &elasticsearchEncoder{}
+)
+
+func newElasticsearchEncoder(cfg *ElasticsearchConfig) (*elasticsearchEncoder, error) {
+	e := &elasticsearchEncoder{cfg: cfg}
+
+	if strings.Contains(cfg.Index, "{{") {
+		tmpl, err := template.New("index").Parse(cfg.Index)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index template '%s': %w", cfg.Index, err)
+		}
+		e.indexTemplate = tmpl
+	}
+
+	return e, nil
+}
+
+func (e *elasticsearchEncoder) Encode(output *generator.GeneratorOutput) (*Payload, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for the elasticsearch protocol")
+	}
+
+	var buf bytes.Buffer
+	for _, log := range output.Logs {
+		if !json.Valid(log.Line) {
+			return nil, fmt.Errorf("the generated log is not a valid JSON document, please use the json format")
+		}
+
+		index, err := e.index(log)
+		if err != nil {
+			return nil, err
+		}
+
+		action, err := json.Marshal(map[string]map[string]string{
+			e.cfg.Action: {"_index": index},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// The action and the document are in the separate lines.
+		buf.Write(action)
+		buf.WriteByte('\n')
+		buf.Write(log.Line)
+		buf.WriteByte('\n')
+	}
+
+	return &Payload{Data: buf.Bytes(), ContentType: ContentTypeNDJSON}, nil
+}
+
+// checkResponse parses the bulk response and counts the failed items since the `_bulk` API responds `200 OK` even if some documents are rejected.
+func (e *elasticsearchEncoder) checkResponse(statusCode int, body []byte) error {
+	if statusCode != http.StatusOK {
+		return &statusCodeError{code: statusCode, body: body}
+	}
+
+	return e.checkBody(body)
+}
+
+// checkBody counts the failed items of the bulk response. It's also used if the status codes are set in the response config.
+func (e *elasticsearchEncoder) checkBody(body []byte) error {
+	var resp struct {
+		Errors bool                                 `json:"errors"`
+		Items  []map[string]elasticsearchBulkResult `json:"items"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("failed to parse the bulk response: %w", err)
+	}
+
+	if !resp.Errors {
+		return nil
+	}
+
+	failure := &partialFailureError{}
+	for _, item := range resp.Items {
+		for _, result := range item {
+			if result.Status < 300 && (len(result.Error) == 0 || string(result.Error) == "null") {
+				continue
+			}
+
+			failure.rejected++
+			if failure.reason == "" {
+				failure.reason = fmt.Sprintf("status '%d' with error '%s'", result.Status, string(result.Error))
+			}
+		}
+	}
+
+	if failure.rejected == 0 {
+		return nil
+	}
+
+	return failure
+}
+
+// elasticsearchBulkResult is the result of each item in the bulk response.
+type elasticsearchBulkResult struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// index returns the index of the log by rendering the index template.
+func (e *elasticsearchEncoder) index(log *logstypes.LogRecord) (string, error) {
+	if e.indexTemplate == nil {
+		return e.cfg.Index, nil
+	}
+
+	data := make(map[string]any, len(log.Fields)+1)
+	data["Timestamp"] = log.Timestamp.In(time.UTC)
+	for _, field := range log.Fields {
+		data[field.Name] = field.Value
+	}
+
+	var buf bytes.Buffer
+	if err := e.indexTemplate.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render the index template: %w", err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+func TestElasticsearchEncoder(t *testing.T) {
+	timestamp, _ := time.Parse(time.RFC3339, "2025-03-23T00:00:00Z")
+	output := &generator.GeneratorOutput{
+		Logs: []*logstypes.LogRecord{
+			{
+				Timestamp: timestamp,
+				Fields:    []*logstypes.LogField{{Name: "level", Key: "level", Value: "info"}},
+				Line:      []byte(`{"level":"info"}`),
+			},
+			{
+				Timestamp: timestamp,
+				Fields:    []*logstypes.LogField{{Name: "level", Key: "level", Value: "error"}},
+				Line:      []byte(`{"level":"error"}`),
+			},
+		},
+	}
+
+	encoder, err := newElasticsearchEncoder(&ElasticsearchConfig{
+		Index:  `logs-{{ .level }}-{{ .Timestamp.Format "2006.01.02" }}`,
+		Action: "create",
+	})
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	payload, err := encoder.Encode(output)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	expected := []string{
+		`{"create":{"_index":"logs-info-2025.03.23"}}`,
+		`{"level":"info"}`,
+		`{"create":{"_index":"logs-error-2025.03.23"}}`,
+		`{"level":"error"}`,
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(payload.Data))
+	for i := 0; scanner.Scan(); i++ {
+		if i >= len(expected) || scanner.Text() != expected[i] {
+			t.Fatalf("unexpected line [%d]: '%s'", i, scanner.Text())
+		}
+	}
+
+	if _, err := encoder.Encode(&generator.GeneratorOutput{Logs: []*logstypes.LogRecord{{Line: []byte("not json")}}}); err == nil {
+		t.Fatalf("expected error for the non-JSON log")
+	}
+}
+
+func TestElasticsearchCheckResponse(t *testing.T) {
+	encoder, err := newElasticsearchEncoder(ElasticsearchConfig{}.defaults())
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		status   int
+		body     any
+		rejected int64
+		wantErr  bool
+	}{
+		{
+			name:   "all documents are accepted",
+			status: 200,
+			body: map[string]any{
+				"errors": false,
+				"items":  []any{map[string]any{"create": map[string]any{"status": 201}}},
+			},
+		},
+		{
+			name:   "part of the documents are rejected",
+			status: 200,
+			body: map[string]any{
+				"errors": true,
+				"items": []any{
+					map[string]any{"create": map[string]any{"status": 201}},
+					map[string]any{"create": map[string]any{"status": 400, "error": map[string]any{"type": "mapper_parsing_exception"}}},
+					map[string]any{"create": map[string]any{"status": 429, "error": map[string]any{"type": "es_rejected_execution_exception"}}},
+				},
+			},
+			rejected: 2,
+			wantErr:  true,
+		},
+		{
+			name:    "the request is rejected",
+			status:  413,
+			body:    map[string]any{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(tt.body)
+			err := encoder.checkResponse(tt.status, body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error '%v', but got '%v'", tt.wantErr, err)
+			}
+
+			var partialErr *partialFailureError
+			if errors.As(err, &partialErr) && partialErr.rejected != tt.rejected {
+				t.Fatalf("expected '%d' rejected records, but got '%d'", tt.rejected, partialErr.rejected)
+			}
+		})
+	}
+}
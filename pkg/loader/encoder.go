@@ -0,0 +1,116 @@
+package loader
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// Encoder encodes the generated data into the payload of the request.
+type Encoder interface {
+	// Encode encodes the generated data into the payload.
+	Encode(output *generator.GeneratorOutput) (*Payload, error)
+}
+
+// messageEncoder encodes the generated data into the protobuf message. It's used by the senders that don't send the raw bytes, for example, gRPC.
+type messageEncoder interface {
+	message(output *generator.GeneratorOutput) (proto.Message, error)
+}
+
+// responseChecker checks whether the response of the target is successful.
+// The HTTP sender uses it instead of accepting the `2xx` status codes if the encoder implements it, unless the status codes are set in the response config,
+// in which case only the bodyChecker of the encoder is used.
+// It returns the *partialFailureError if only part of the records are rejected.
+type responseChecker interface {
+	checkResponse(statusCode int, body []byte) error
+}
+
+// bodyChecker checks the body of the response whose status code is accepted by the response config.
+// The encoder implements it if the target may reject part of the records with the successful status code, for example, Elasticsearch.
+type bodyChecker interface {
+	checkBody(body []byte) error
+}
+
+// partialFailureError is returned when the target accepts the request but rejects part of the records.
+type partialFailureError struct {
+	// rejected is the number of the rejected records.
+	rejected int64
+
+	// reason is the reason of the rejection, for example, the error message of the first rejected record.
+	reason string
+}
+
+func (e *partialFailureError) Error() string {
+	return fmt.Sprintf("'%d' records are rejected: %s", e.rejected, e.reason)
+}
+
+func (e *partialFailureError) FailureClass() string {
+	return failureClassPartial
+}
+
+// Payload is the encoded data that will be sent to the target.
+type Payload struct {
+	// Data is the encoded data.
+	Data []byte
+
+	// ContentType is the content type of the encoded data. It can be overridden by the headers in the config.
+	ContentType string
+
+	// Headers is the extra headers required by the protocol. It can be overridden by the headers in the config.
+	Headers map[string]string
+
+	// RawSize is the size of the encoded data before the compression required by the protocol, for example, snappy for Loki.
+	// It's 0 if the data is not compressed by the protocol.
+	RawSize int
+}
+
+// rawSize returns the size of the encoded data before any compression.
+func (p *Payload) rawSize() int64 {
+	if p.RawSize > 0 {
+		return int64(p.RawSize)
+	}
+
+	return int64(len(p.Data))
+}
+
+// NewEncoder creates a new Encoder by the protocol in the config.
+func NewEncoder(cfg *Config) (Encoder, error) {
+	switch cfg.Protocol {
+	case ProtocolRaw, "":
+		return &rawEncoder{}, nil
+	case ProtocolOTLP:
+		otlpCfg := cfg.OTLP
+		if otlpCfg == nil {
+			otlpCfg = OTLPConfig{}.defaults()
+		}
+		return &otlpEncoder{cfg: otlpCfg, startTime: time.Now()}, nil
+	case ProtocolLoki:
+		lokiCfg := cfg.Loki
+		if lokiCfg == nil {
+			lokiCfg = LokiConfig{}.defaults()
+		}
+		return newLokiEncoder(lokiCfg), nil
+	case ProtocolElasticsearch:
+		esCfg := cfg.Elasticsearch
+		if esCfg == nil {
+			esCfg = ElasticsearchConfig{}.defaults()
+		}
+		return newElasticsearchEncoder(esCfg)
+	case ProtocolRemoteWrite:
+		return &remoteWriteEncoder{}, nil
+	}
+
+	return nil, fmt.Errorf("invalid protocol: '%s'", cfg.Protocol)
+}
+
+// rawEncoder uses the generated data as the payload without any conversion.
+type rawEncoder struct{}
+
+var _ Encoder = &rawEncoder{}
+
+func (e *rawEncoder) Encode(output *generator.GeneratorOutput) (*Payload, error) {
+	return &Payload{Data: output.Data}, nil
+}
@@ -0,0 +1,78 @@
+package loader
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+)
+
+const (
+	// failureClassGenerate is the failure class of the errors of the generator.
+	failureClassGenerate = "generate"
+
+	// failureClassEncode is the failure class of the errors of the encoder.
+	failureClassEncode = "encode"
+
+	// failureClassBodyRead is the failure class of the errors when reading the response body.
+	failureClassBodyRead = "body read"
+
+	// failureClassPartial is the failure class of the requests that part of the records are rejected.
+	failureClassPartial = "partial failure"
+
+	// failureClassAssertion is the failure class of the responses that fail the assertions of the response body.
+	failureClassAssertion = "response assertion"
+)
+
+// classifiedError is the error with its failure class in the stats.
+type classifiedError struct {
+	class string
+	err   error
+}
+
+var _ collector.FailureClassifier = &classifiedError{}
+
+func (e *classifiedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+func (e *classifiedError) FailureClass() string {
+	return e.class
+}
+
+// staleConnectionError is returned when the reused connection is closed by the target before the response,
+// for example, the idle timeout of the target is shorter than the client's. The request is retried on a new connection.
+type staleConnectionError struct {
+	err error
+}
+
+func (e *staleConnectionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *staleConnectionError) Unwrap() error {
+	return e.err
+}
+
+// statusCodeError is returned when the target responds with an unexpected HTTP status code.
+type statusCodeError struct {
+	code int
+	body []byte
+
+	// retryAfter is the delay requested by the `Retry-After` header of the response. It's 0 if the header is not set.
+	retryAfter time.Duration
+}
+
+var _ collector.FailureClassifier = &statusCodeError{}
+
+func (e *statusCodeError) Error() string {
+	return fmt.Sprintf("unexpected status code '%d' and body '%s'", e.code, string(e.body))
+}
+
+func (e *statusCodeError) FailureClass() string {
+	return fmt.Sprintf("HTTP %d", e.code)
+}
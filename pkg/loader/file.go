@@ -0,0 +1,295 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/utils"
+)
+
+// fileSender writes the generated logs into the files on disk. The requests are spread across the files in the round-robin order,
+// so all the files are written even if there are more files than the workers.
+type fileSender struct {
+	cfg   *FileConfig
+	files []*logFile
+
+	// next is the index of the file for the next request.
+	next atomic.Uint64
+
+	// stop stops the background fsync.
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+var _ sender = &fileSender{}
+
+func newFileSender(cfg *FileConfig) (*fileSender, error) {
+	var maxSize int64
+	if cfg.Rotation != nil && cfg.Rotation.MaxSize != "" {
+		size, err := utils.ParseSize(cfg.Rotation.MaxSize)
+		if err != nil {
+			return nil, err
+		}
+		maxSize = size
+	}
+
+	s := &fileSender{cfg: cfg, stop: make(chan struct{})}
+	for _, path := range filePaths(cfg.Path, cfg.Files) {
+		file, err := openLogFile(path, cfg.Rotation, maxSize)
+		if err != nil {
+			s.closeFiles()
+			return nil, err
+		}
+		s.files = append(s.files, file)
+	}
+
+	if cfg.Fsync == FileFsyncInterval {
+		s.wg.Add(1)
+		go s.syncLoop()
+	}
+
+	return s, nil
+}
+
+func (s *fileSender) send(_ context.Context, _ *worker, output *generator.GeneratorOutput) (*sendResult, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for file")
+	}
+
+	data := logstypes.JoinLines(output.Logs)
+	file := s.files[(s.next.Add(1)-1)%uint64(len(s.files))]
+	if err := file.write(data, s.cfg.Fsync == FileFsyncAlways); err != nil {
+		return nil, err
+	}
+
+	return &sendResult{rawBytes: int64(len(data)), wireBytes: int64(len(data))}, nil
+}
+
+func (s *fileSender) close() error {
+	close(s.stop)
+	s.wg.Wait()
+
+	return s.closeFiles()
+}
+
+func (s *fileSender) closeFiles() error {
+	var lastErr error
+	for _, file := range s.files {
+		if err := file.close(); err != nil {
+			lastErr = err
+		}
+	}
+
+	return lastErr
+}
+
+func (s *fileSender) syncLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.cfg.FsyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			for _, file := range s.files {
+				if err := file.sync(); err != nil {
+					fmt.Printf("failed to fsync the file '%s': %v\n", file.path, err)
+				}
+			}
+		}
+	}
+}
+
+// filePaths returns the paths of the files. The index of the file is added to the file name if there are multiple files.
+func filePaths(path string, count int) []string {
+	if count == 1 {
+		return []string{path}
+	}
+
+	var (
+		ext   = filepath.Ext(path)
+		base  = strings.TrimSuffix(path, ext)
+		paths = make([]string, 0, count)
+	)
+	for i := 0; i < count; i++ {
+		paths = append(paths, fmt.Sprintf("%s-%d%s", base, i, ext))
+	}
+
+	return paths
+}
+
+// logFile is a log file that can be rotated.
+type logFile struct {
+	mu       sync.Mutex
+	path     string
+	rotation *FileRotationConfig
+	maxSize  int64
+
+	file     *os.File
+	size     int64
+	openedAt time.Time
+}
+
+func openLogFile(path string, rotation *FileRotationConfig, maxSize int64) (*logFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	f := &logFile{path: path, rotation: rotation, maxSize: maxSize}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *logFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+
+	return nil
+}
+
+func (f *logFile) write(data []byte, fsync bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.shouldRotate(int64(len(data))) {
+		if err := f.rotate(); err != nil {
+			return fmt.Errorf("failed to rotate the file '%s': %w", f.path, err)
+		}
+	}
+
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if fsync {
+		return f.file.Sync()
+	}
+
+	return nil
+}
+
+func (f *logFile) shouldRotate(size int64) bool {
+	if f.rotation == nil || f.size == 0 {
+		return false
+	}
+
+	if f.maxSize > 0 && f.size+size > f.maxSize {
+		return true
+	}
+
+	return f.rotation.Interval > 0 && time.Since(f.openedAt) >= f.rotation.Interval
+}
+
+// rotate moves the current content of the file to the backup `<path>.1`, and the existing backups are shifted by one.
+func (f *logFile) rotate() error {
+	if err := f.shiftBackups(); err != nil {
+		return err
+	}
+
+	if f.rotation.Strategy == FileRotationCopyTruncate {
+		if err := copyFile(f.path, f.backupPath(1)); err != nil {
+			return err
+		}
+
+		// The file is opened in the append mode, so the next write starts from the beginning after the truncation.
+		if err := f.file.Truncate(0); err != nil {
+			return err
+		}
+		f.size = 0
+		f.openedAt = time.Now()
+
+		return nil
+	}
+
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.path, f.backupPath(1)); err != nil {
+		return err
+	}
+
+	return f.open()
+}
+
+// shiftBackups renames `<path>.N` to `<path>.N+1` and removes the oldest backup that exceeds the max backups.
+func (f *logFile) shiftBackups() error {
+	if err := os.Remove(f.backupPath(f.rotation.MaxBackups)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	for i := f.rotation.MaxBackups - 1; i >= 1; i-- {
+		if err := os.Rename(f.backupPath(i), f.backupPath(i+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (f *logFile) backupPath(index int) string {
+	return fmt.Sprintf("%s.%d", f.path, index)
+}
+
+func (f *logFile) sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Sync()
+}
+
+func (f *logFile) close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.file.Close()
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
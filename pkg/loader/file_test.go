@@ -0,0 +1,154 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+func testFileOutput(request int) *generator.GeneratorOutput {
+	output := &generator.GeneratorOutput{}
+	for i := 0; i < 10; i++ {
+		output.Logs = append(output.Logs, &logstypes.LogRecord{Line: []byte(fmt.Sprintf("request %03d log %d", request, i))})
+	}
+
+	return output
+}
+
+func TestFileSender(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    int
+		fsync    string
+		rotation *FileRotationConfig
+	}{
+		{name: "without rotation", files: 1, fsync: FileFsyncNever},
+		{name: "multiple files", files: 3, fsync: FileFsyncAlways},
+		{name: "more files than workers", files: 5, fsync: FileFsyncNever},
+		{name: "rename rotation", files: 1, fsync: FileFsyncInterval, rotation: &FileRotationConfig{MaxSize: "1kb", Strategy: FileRotationRename, MaxBackups: 100}},
+		{name: "copytruncate rotation", files: 2, fsync: FileFsyncNever, rotation: &FileRotationConfig{MaxSize: "1kb", Strategy: FileRotationCopyTruncate, MaxBackups: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			cfg := FileConfig{}.defaults()
+			cfg.Path = filepath.Join(dir, "app.log")
+			cfg.Files = tt.files
+			cfg.Fsync = tt.fsync
+			cfg.Rotation = tt.rotation
+
+			sender, err := newFileSender(cfg)
+			if err != nil {
+				t.Fatalf("failed to create file sender: %v", err)
+			}
+
+			const requests = 30
+			for i := 0; i < requests; i++ {
+				if _, err := sender.send(context.Background(), &worker{id: i % 3}, testFileOutput(i)); err != nil {
+					t.Fatalf("failed to send: %v", err)
+				}
+			}
+
+			if err := sender.close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("failed to read dir: %v", err)
+			}
+
+			var (
+				lines   int
+				rotated int
+				maxSize int64
+			)
+			if tt.rotation != nil {
+				maxSize = 1000
+			}
+			for _, entry := range entries {
+				data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+				if err != nil {
+					t.Fatalf("failed to read file: %v", err)
+				}
+				lines += strings.Count(string(data), "\n")
+
+				// The requests of the 3 workers are spread across all the files.
+				if tt.rotation == nil && strings.Count(string(data), "\n") != requests*10/tt.files {
+					t.Fatalf("unexpected lines of the file '%s': '%d', expected: '%d'", entry.Name(), strings.Count(string(data), "\n"), requests*10/tt.files)
+				}
+
+				if maxSize > 0 && int64(len(data)) > maxSize {
+					t.Fatalf("the size of the file '%s' exceeds the max size: %d", entry.Name(), len(data))
+				}
+
+				if filepath.Ext(entry.Name()) != ".log" {
+					rotated++
+				}
+			}
+
+			if lines != requests*10 {
+				t.Fatalf("unexpected lines: '%d', expected: '%d'", lines, requests*10)
+			}
+
+			if tt.rotation == nil && len(entries) != tt.files {
+				t.Fatalf("unexpected files: '%d', expected: '%d'", len(entries), tt.files)
+			}
+
+			if tt.rotation != nil && rotated == 0 {
+				t.Fatalf("the files are not rotated")
+			}
+		})
+	}
+}
+
+func TestFileSenderMaxBackups(t *testing.T) {
+	dir := t.TempDir()
+	cfg := FileConfig{}.defaults()
+	cfg.Path = filepath.Join(dir, "app.log")
+	cfg.Rotation = &FileRotationConfig{MaxSize: "100bytes", Strategy: FileRotationRename, MaxBackups: 2}
+
+	sender, err := newFileSender(cfg)
+	if err != nil {
+		t.Fatalf("failed to create file sender: %v", err)
+	}
+	defer sender.close()
+
+	for i := 0; i < 10; i++ {
+		if _, err := sender.send(context.Background(), &worker{id: 0}, testFileOutput(i)); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read dir: %v", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	expected := []string{"app.log", "app.log.1", "app.log.2"}
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Fatalf("unexpected files: '%v', expected: '%v'", names, expected)
+	}
+
+	// The newest backup has the logs of the previous request.
+	data, err := os.ReadFile(filepath.Join(dir, "app.log.1"))
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	if !strings.HasPrefix(string(data), "request 008") {
+		t.Fatalf("unexpected content of the newest backup: '%s'", data)
+	}
+}
@@ -0,0 +1,351 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+// eventTimeExtType is the msgpack extension type of the EventTime in the Forward protocol.
+const eventTimeExtType = 0
+
+// forwardSender sends the generated logs to the Fluentd/Fluent Bit by the Forward protocol. Each worker has its own connection.
+type forwardSender struct {
+	cfg     *ForwardConfig
+	address string
+	conns   *workerConns
+}
+
+var _ sender = &forwardSender{}
+
+func newForwardSender(cfg *ForwardConfig) *forwardSender {
+	s := &forwardSender{
+		cfg:     cfg,
+		address: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+	}
+	s.conns = newWorkerConns(s.dial)
+
+	return s
+}
+
+func (s *forwardSender) send(_ context.Context, w *worker, output *generator.GeneratorOutput) (*sendResult, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for forward")
+	}
+
+	data, chunks, rawBytes, err := s.encode(output.Logs)
+	if err != nil {
+		return nil, &classifiedError{class: failureClassEncode, err: err}
+	}
+
+	conn, err := s.conns.get(w.id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.Timeout > 0 {
+		if err := conn.SetDeadline(time.Now().Add(s.cfg.Timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := conn.Write(data); err != nil {
+		s.conns.reset(w.id)
+		return nil, err
+	}
+	result := &sendResult{rawBytes: rawBytes, wireBytes: int64(len(data))}
+
+	if len(chunks) > 0 {
+		if err := readAcks(conn, chunks); err != nil {
+			s.conns.reset(w.id)
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+func (s *forwardSender) close() error {
+	return s.conns.close()
+}
+
+func (s *forwardSender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+	return dialer.Dial("tcp", s.address)
+}
+
+// encode encodes the logs into the Forward messages. It returns the chunk IDs of the messages if the acknowledgement is required,
+// and the size of the messages before the compression.
+func (s *forwardSender) encode(logs []*logstypes.LogRecord) ([]byte, []string, int64, error) {
+	var (
+		buf    bytes.Buffer
+		chunks []string
+		enc    = msgpack.NewEncoder(&buf)
+
+		// saved is the size reduced by the compression of the `compressedPackedForward` mode.
+		saved int64
+	)
+
+	for _, group := range s.groupByTag(logs) {
+		if s.cfg.Mode == ForwardModeMessage {
+			for _, log := range group.logs {
+				chunk, err := s.encodeMessage(enc, group.tag, log)
+				if err != nil {
+					return nil, nil, 0, err
+				}
+				if chunk != "" {
+					chunks = append(chunks, chunk)
+				}
+			}
+			continue
+		}
+
+		chunk, reduced, err := s.encodeForward(enc, group.tag, group.logs)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		saved += reduced
+	}
+
+	return buf.Bytes(), chunks, int64(buf.Len()) + saved, nil
+}
+
+// encodeMessage encodes the log as `[tag, time, record, option]`.
+func (s *forwardSender) encodeMessage(enc *msgpack.Encoder, tag string, log *logstypes.LogRecord) (string, error) {
+	if err := enc.EncodeArrayLen(4); err != nil {
+		return "", err
+	}
+
+	if err := enc.EncodeString(tag); err != nil {
+		return "", err
+	}
+
+	if err := encodeEventTime(enc, log.Timestamp); err != nil {
+		return "", err
+	}
+
+	if err := s.encodeRecord(enc, log); err != nil {
+		return "", err
+	}
+
+	return s.encodeOption(enc, 1, "")
+}
+
+// encodeForward encodes the logs as `[tag, entries, option]`. The entries are an array in the `forward` mode and the msgpack binary in the packed modes.
+// It also returns the size reduced by the compression.
+func (s *forwardSender) encodeForward(enc *msgpack.Encoder, tag string, logs []*logstypes.LogRecord) (string, int64, error) {
+	if err := enc.EncodeArrayLen(3); err != nil {
+		return "", 0, err
+	}
+
+	if err := enc.EncodeString(tag); err != nil {
+		return "", 0, err
+	}
+
+	if s.cfg.Mode == ForwardModeForward {
+		if err := enc.EncodeArrayLen(len(logs)); err != nil {
+			return "", 0, err
+		}
+
+		for _, log := range logs {
+			if err := s.encodeEntry(enc, log); err != nil {
+				return "", 0, err
+			}
+		}
+
+		chunk, err := s.encodeOption(enc, len(logs), "")
+		return chunk, 0, err
+	}
+
+	var (
+		entries    bytes.Buffer
+		entriesEnc = msgpack.NewEncoder(&entries)
+	)
+	for _, log := range logs {
+		if err := s.encodeEntry(entriesEnc, log); err != nil {
+			return "", 0, err
+		}
+	}
+
+	var (
+		packed     = entries.Bytes()
+		compressed string
+		reduced    int64
+	)
+	if s.cfg.Mode == ForwardModeCompressedPackedForward {
+		var gzipped bytes.Buffer
+		gw := gzip.NewWriter(&gzipped)
+		if _, err := gw.Write(packed); err != nil {
+			return "", 0, err
+		}
+		if err := gw.Close(); err != nil {
+			return "", 0, err
+		}
+		reduced = int64(len(packed) - gzipped.Len())
+		packed = gzipped.Bytes()
+		compressed = "gzip"
+	}
+
+	if err := enc.EncodeBytes(packed); err != nil {
+		return "", 0, err
+	}
+
+	chunk, err := s.encodeOption(enc, len(logs), compressed)
+	return chunk, reduced, err
+}
+
+// encodeEntry encodes the log as `[time, record]`.
+func (s *forwardSender) encodeEntry(enc *msgpack.Encoder, log *logstypes.LogRecord) error {
+	if err := enc.EncodeArrayLen(2); err != nil {
+		return err
+	}
+
+	if err := encodeEventTime(enc, log.Timestamp); err != nil {
+		return err
+	}
+
+	return s.encodeRecord(enc, log)
+}
+
+// encodeRecord encodes the fields of the log as a map.
+func (s *forwardSender) encodeRecord(enc *msgpack.Encoder, log *logstypes.LogRecord) error {
+	size := len(log.Fields)
+	if s.cfg.LineKey != "" {
+		size++
+	}
+
+	if err := enc.EncodeMapLen(size); err != nil {
+		return err
+	}
+
+	for _, field := range log.Fields {
+		if err := enc.EncodeString(field.Key); err != nil {
+			return err
+		}
+
+		value := field.Value
+		if t, ok := value.(time.Time); ok {
+			value = t.Format(time.RFC3339Nano)
+		}
+
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.LineKey != "" {
+		if err := enc.EncodeString(s.cfg.LineKey); err != nil {
+			return err
+		}
+
+		if err := enc.EncodeString(string(log.Line)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeOption encodes the option map of the message. It returns the chunk ID if the acknowledgement is required.
+func (s *forwardSender) encodeOption(enc *msgpack.Encoder, size int, compressed string) (string, error) {
+	option := map[string]any{"size": size}
+
+	if compressed != "" {
+		option["compressed"] = compressed
+	}
+
+	var chunk string
+	if s.cfg.RequireAck {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return "", err
+		}
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+
+	if err := enc.Encode(option); err != nil {
+		return "", err
+	}
+
+	return chunk, nil
+}
+
+type forwardGroup struct {
+	tag  string
+	logs []*logstypes.LogRecord
+}
+
+// groupByTag groups the logs by the tag in the order of the first appearance of the tag.
+func (s *forwardSender) groupByTag(logs []*logstypes.LogRecord) []*forwardGroup {
+	if s.cfg.TagToken == "" {
+		return []*forwardGroup{{tag: s.cfg.Tag, logs: logs}}
+	}
+
+	var (
+		groups []*forwardGroup
+		index  = make(map[string]*forwardGroup)
+	)
+	for _, log := range logs {
+		tag := s.cfg.Tag
+		if field := log.Field(s.cfg.TagToken); field != nil {
+			tag = fmt.Sprintf("%s.%v", s.cfg.Tag, field.Value)
+		}
+
+		group, ok := index[tag]
+		if !ok {
+			group = &forwardGroup{tag: tag}
+			index[tag] = group
+			groups = append(groups, group)
+		}
+		group.logs = append(group.logs, log)
+	}
+
+	return groups
+}
+
+// encodeEventTime encodes the timestamp as the EventTime extension, which has the seconds and the nanoseconds in the big-endian uint32.
+func encodeEventTime(enc *msgpack.Encoder, t time.Time) error {
+	if err := enc.EncodeExtHeader(eventTimeExtType, 8); err != nil {
+		return err
+	}
+
+	var b [8]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(t.Unix()))
+	binary.BigEndian.PutUint32(b[4:], uint32(t.Nanosecond()))
+
+	_, err := enc.Writer().Write(b[:])
+	return err
+}
+
+// readAcks reads the acknowledgements of the messages in order and checks the chunk IDs.
+func readAcks(conn net.Conn, chunks []string) error {
+	dec := msgpack.NewDecoder(conn)
+	for _, chunk := range chunks {
+		resp, err := dec.DecodeMap()
+		if err != nil {
+			return fmt.Errorf("failed to read the ack: %w", err)
+		}
+
+		if ack, _ := resp["ack"].(string); ack != chunk {
+			return fmt.Errorf("unexpected ack: '%v', expected: '%s'", resp["ack"], chunk)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,328 @@
+package loader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+type forwardEntry struct {
+	timestamp time.Time
+	record    map[string]any
+}
+
+type forwardMessage struct {
+	tag     string
+	entries []*forwardEntry
+	option  map[string]any
+}
+
+func testForwardOutput() *generator.GeneratorOutput {
+	timestamp := time.Date(2025, 3, 23, 0, 0, 0, 123456789, time.UTC)
+	output := &generator.GeneratorOutput{}
+	for i, service := range []string{"api", "db", "api"} {
+		output.Logs = append(output.Logs, &logstypes.LogRecord{
+			Timestamp: timestamp,
+			Fields: []*logstypes.LogField{
+				{Name: "service", Key: "service", Value: service},
+				{Name: "status", Key: "http.status", Value: 200 + i},
+			},
+			Line: []byte(fmt.Sprintf("%s %d", service, 200+i)),
+		})
+	}
+
+	return output
+}
+
+func TestForwardSender(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         ForwardMode
+		tagToken     string
+		requireAck   bool
+		wantMessages int
+		wantTags     []string
+	}{
+		{name: "message", mode: ForwardModeMessage, wantMessages: 3, wantTags: []string{"o11ybench", "o11ybench", "o11ybench"}},
+		{name: "forward", mode: ForwardModeForward, wantMessages: 1, wantTags: []string{"o11ybench"}},
+		{name: "forward with tag token", mode: ForwardModeForward, tagToken: "service", wantMessages: 2, wantTags: []string{"o11ybench.api", "o11ybench.db"}},
+		{name: "packed forward", mode: ForwardModePackedForward, wantMessages: 1, wantTags: []string{"o11ybench"}},
+		{name: "compressed packed forward with ack", mode: ForwardModeCompressedPackedForward, requireAck: true, wantMessages: 1, wantTags: []string{"o11ybench"}},
+		{name: "message with ack", mode: ForwardModeMessage, requireAck: true, wantMessages: 3, wantTags: []string{"o11ybench", "o11ybench", "o11ybench"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatalf("failed to listen: %v", err)
+			}
+			defer listener.Close()
+
+			cfg := ForwardConfig{}.defaults()
+			cfg.Host = "127.0.0.1"
+			cfg.Port = listener.Addr().(*net.TCPAddr).Port
+			cfg.Mode = tt.mode
+			cfg.TagToken = tt.tagToken
+			cfg.LineKey = "log"
+			cfg.RequireAck = tt.requireAck
+
+			received := make(chan []*forwardMessage, 1)
+			errs := make(chan error, 1)
+			go func() {
+				conn, err := listener.Accept()
+				if err != nil {
+					errs <- err
+					return
+				}
+				defer conn.Close()
+
+				dec := msgpack.NewDecoder(conn)
+				enc := msgpack.NewEncoder(conn)
+
+				var messages []*forwardMessage
+				for len(messages) < tt.wantMessages {
+					message, err := readForwardMessage(dec)
+					if err != nil {
+						errs <- err
+						return
+					}
+					messages = append(messages, message)
+
+					if chunk, ok := message.option["chunk"]; ok {
+						if err := enc.Encode(map[string]any{"ack": chunk}); err != nil {
+							errs <- err
+							return
+						}
+					}
+				}
+				received <- messages
+			}()
+
+			sender := newForwardSender(cfg)
+			defer sender.close()
+
+			output := testForwardOutput()
+			result, err := sender.send(context.Background(), &worker{id: 0}, output)
+			if err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			if compressed := tt.mode == ForwardModeCompressedPackedForward; compressed == (result.rawBytes == result.wireBytes) {
+				t.Fatalf("unexpected raw bytes '%d' and wire bytes '%d' for the mode '%s'", result.rawBytes, result.wireBytes, tt.mode)
+			}
+
+			var messages []*forwardMessage
+			select {
+			case messages = <-received:
+			case err := <-errs:
+				t.Fatalf("failed to receive the messages: %v", err)
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout to receive the messages")
+			}
+
+			var (
+				tags    []string
+				entries []*forwardEntry
+			)
+			for _, message := range messages {
+				tags = append(tags, message.tag)
+				entries = append(entries, message.entries...)
+
+				if fmt.Sprint(message.option["size"]) != fmt.Sprint(len(message.entries)) {
+					t.Fatalf("unexpected size in the option: '%v'", message.option["size"])
+				}
+
+				if _, ok := message.option["chunk"]; ok != tt.requireAck {
+					t.Fatalf("unexpected chunk in the option: '%v'", message.option)
+				}
+			}
+
+			if fmt.Sprint(tags) != fmt.Sprint(tt.wantTags) {
+				t.Fatalf("unexpected tags: '%v', expected: '%v'", tags, tt.wantTags)
+			}
+
+			if len(entries) != len(output.Logs) {
+				t.Fatalf("unexpected entries count: '%d', expected: '%d'", len(entries), len(output.Logs))
+			}
+
+			for _, entry := range entries {
+				if !entry.timestamp.Equal(output.Logs[0].Timestamp) {
+					t.Fatalf("unexpected timestamp: '%v'", entry.timestamp)
+				}
+
+				if _, ok := entry.record["http.status"]; !ok {
+					t.Fatalf("the display name is not used as the key: '%v'", entry.record)
+				}
+
+				if entry.record["log"] != fmt.Sprintf("%s %v", entry.record["service"], entry.record["http.status"]) {
+					t.Fatalf("unexpected log line: '%v'", entry.record)
+				}
+			}
+		})
+	}
+}
+
+func TestForwardSenderAckMismatch(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		if _, err := readForwardMessage(msgpack.NewDecoder(conn)); err != nil {
+			return
+		}
+		msgpack.NewEncoder(conn).Encode(map[string]any{"ack": "unknown"})
+	}()
+
+	cfg := ForwardConfig{}.defaults()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = listener.Addr().(*net.TCPAddr).Port
+	cfg.RequireAck = true
+
+	sender := newForwardSender(cfg)
+	defer sender.close()
+
+	if _, err := sender.send(context.Background(), &worker{id: 0}, testForwardOutput()); err == nil {
+		t.Fatalf("expected an error for the mismatched ack")
+	}
+}
+
+// readForwardMessage decodes a message of any mode of the Forward protocol.
+func readForwardMessage(dec *msgpack.Decoder) (*forwardMessage, error) {
+	n, err := dec.DecodeArrayLen()
+	if err != nil {
+		return nil, err
+	}
+
+	message := &forwardMessage{}
+	if message.tag, err = dec.DecodeString(); err != nil {
+		return nil, err
+	}
+
+	switch n {
+	case 4:
+		entry, err := readForwardEntry(dec)
+		if err != nil {
+			return nil, err
+		}
+		message.entries = append(message.entries, entry)
+	case 3:
+		code, err := dec.PeekCode()
+		if err != nil {
+			return nil, err
+		}
+
+		if msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32 {
+			count, err := dec.DecodeArrayLen()
+			if err != nil {
+				return nil, err
+			}
+
+			for i := 0; i < count; i++ {
+				if _, err := dec.DecodeArrayLen(); err != nil {
+					return nil, err
+				}
+
+				entry, err := readForwardEntry(dec)
+				if err != nil {
+					return nil, err
+				}
+				message.entries = append(message.entries, entry)
+			}
+			break
+		}
+
+		packed, err := dec.DecodeBytes()
+		if err != nil {
+			return nil, err
+		}
+		message.option, err = dec.DecodeMap()
+		if err != nil {
+			return nil, err
+		}
+
+		if message.option["compressed"] == "gzip" {
+			reader, err := gzip.NewReader(bytes.NewReader(packed))
+			if err != nil {
+				return nil, err
+			}
+
+			if packed, err = io.ReadAll(reader); err != nil {
+				return nil, err
+			}
+		}
+
+		entriesDec := msgpack.NewDecoder(bytes.NewReader(packed))
+		for {
+			if _, err := entriesDec.DecodeArrayLen(); err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, err
+			}
+
+			entry, err := readForwardEntry(entriesDec)
+			if err != nil {
+				return nil, err
+			}
+			message.entries = append(message.entries, entry)
+		}
+
+		return message, nil
+	default:
+		return nil, fmt.Errorf("unexpected message length: %d", n)
+	}
+
+	if message.option, err = dec.DecodeMap(); err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// readForwardEntry decodes the EventTime and the record of an entry.
+func readForwardEntry(dec *msgpack.Decoder) (*forwardEntry, error) {
+	extID, extLen, err := dec.DecodeExtHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	if extID != eventTimeExtType || extLen != 8 {
+		return nil, fmt.Errorf("unexpected ext: id %d, length %d", extID, extLen)
+	}
+
+	b := make([]byte, 8)
+	if err := dec.ReadFull(b); err != nil {
+		return nil, err
+	}
+
+	record, err := dec.DecodeMap()
+	if err != nil {
+		return nil, err
+	}
+
+	return &forwardEntry{
+		timestamp: time.Unix(int64(binary.BigEndian.Uint32(b[:4])), int64(binary.BigEndian.Uint32(b[4:]))),
+		record:    record,
+	}, nil
+}
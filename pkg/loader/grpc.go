@@ -0,0 +1,229 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// grpcSender sends the generated data to the OTLP gRPC receiver.
+type grpcSender struct {
+	cfg      *GRPCConfig
+	encoder  messageEncoder
+	metadata metadata.MD
+
+	// auth provides the authentication metadata. It's nil if the auth is not set.
+	auth  *authProvider
+	conns []*grpcConn
+}
+
+// grpcConn is a gRPC connection in the pool with the OTLP service clients.
+type grpcConn struct {
+	conn    *grpc.ClientConn
+	logs    collogspb.LogsServiceClient
+	metrics colmetricspb.MetricsServiceClient
+}
+
+var _ sender = &grpcSender{}
+
+func newGRPCSender(cfg *GRPCConfig, encoder Encoder) (*grpcSender, error) {
+	messageEncoder, ok := encoder.(messageEncoder)
+	if !ok {
+		return nil, fmt.Errorf("the protocol is not supported by grpc")
+	}
+
+	s := &grpcSender{
+		cfg:      cfg,
+		encoder:  messageEncoder,
+		metadata: metadata.New(cfg.Headers),
+	}
+
+	creds := insecure.NewCredentials()
+	if cfg.TLS != nil {
+		tlsConfig, err := cfg.TLS.tlsConfig(cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		creds = credentials.NewTLS(tlsConfig)
+	}
+
+	opts := []grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(&grpcStatsHandler{}),
+	}
+
+	if cfg.Compression == "gzip" {
+		opts = append(opts, grpc.WithDefaultCallOptions(grpc.UseCompressor(gzip.Name)))
+	}
+
+	if cfg.Auth != nil {
+		auth, err := newAuthProvider(cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+		s.auth = auth
+	}
+
+	target := net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))
+	for i := 0; i < cfg.Connections; i++ {
+		conn, err := grpc.NewClient(target, opts...)
+		if err != nil {
+			s.close()
+			return nil, err
+		}
+
+		s.conns = append(s.conns, &grpcConn{
+			conn:    conn,
+			logs:    collogspb.NewLogsServiceClient(conn),
+			metrics: colmetricspb.NewMetricsServiceClient(conn),
+		})
+	}
+
+	return s, nil
+}
+
+func (s *grpcSender) send(ctx context.Context, w *worker, output *generator.GeneratorOutput) (*sendResult, error) {
+	message, err := s.encoder.message(output)
+	if err != nil {
+		return nil, &classifiedError{class: failureClassEncode, err: err}
+	}
+
+	// The stats handler fills the result when the message is sent.
+	result := &sendResult{}
+	ctx = context.WithValue(ctx, sendResultKey{}, result)
+
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	md := s.metadata
+	if s.auth != nil {
+		// The credential may be refreshed, so it's added to a copy of the metadata for each request.
+		md = md.Copy()
+		name, value := s.auth.header()
+		md.Set(name, value)
+	}
+
+	if len(md) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, md)
+	}
+
+	// Each worker always uses the same connection in the pool.
+	conn := s.conns[w.id%len(s.conns)]
+
+	if err := s.export(ctx, conn, message); err != nil {
+		err = classifyGRPCError(err)
+		if result.wireBytes == 0 {
+			return nil, err
+		}
+
+		return result, err
+	}
+
+	return result, nil
+}
+
+func (s *grpcSender) export(ctx context.Context, conn *grpcConn, message proto.Message) error {
+	switch request := message.(type) {
+	case *collogspb.ExportLogsServiceRequest:
+		resp, err := conn.logs.Export(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if rejected := resp.GetPartialSuccess().GetRejectedLogRecords(); rejected > 0 {
+			return &partialFailureError{rejected: rejected, reason: resp.GetPartialSuccess().GetErrorMessage()}
+		}
+	case *colmetricspb.ExportMetricsServiceRequest:
+		resp, err := conn.metrics.Export(ctx, request)
+		if err != nil {
+			return err
+		}
+
+		if rejected := resp.GetPartialSuccess().GetRejectedDataPoints(); rejected > 0 {
+			return &partialFailureError{rejected: rejected, reason: resp.GetPartialSuccess().GetErrorMessage()}
+		}
+	default:
+		return fmt.Errorf("unsupported otlp message type '%T'", message)
+	}
+
+	return nil
+}
+
+// classifyGRPCError classifies the error by the gRPC status code, for example, `gRPC Unavailable`.
+func classifyGRPCError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	if st.Code() == codes.DeadlineExceeded {
+		return &classifiedError{class: collector.FailureClassTimeout, err: err}
+	}
+
+	return &classifiedError{class: fmt.Sprintf("gRPC %s", st.Code()), err: err}
+}
+
+// sendResultKey is the context key of the *sendResult of the gRPC call.
+type sendResultKey struct{}
+
+// grpcStatsHandler records the size of the outgoing message into the *sendResult in the context of the call.
+type grpcStatsHandler struct{}
+
+var _ stats.Handler = &grpcStatsHandler{}
+
+func (h *grpcStatsHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (h *grpcStatsHandler) HandleRPC(ctx context.Context, rs stats.RPCStats) {
+	out, ok := rs.(*stats.OutPayload)
+	if !ok || !out.IsClient() {
+		return
+	}
+
+	if result, ok := ctx.Value(sendResultKey{}).(*sendResult); ok {
+		// The wire length includes the compression and the gRPC message header.
+		result.rawBytes = int64(out.Length)
+		result.wireBytes = int64(out.WireLength)
+	}
+}
+
+func (h *grpcStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *grpcStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
+
+func (s *grpcSender) close() error {
+	var lastErr error
+	for _, c := range s.conns {
+		if err := c.conn.Close(); err != nil {
+			lastErr = err
+		}
+	}
+
+	if s.auth != nil {
+		s.auth.close()
+	}
+
+	return lastErr
+}
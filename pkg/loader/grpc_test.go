@@ -0,0 +1,217 @@
+package loader
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+type mockLogsGenerator struct{}
+
+var _ generator.Generator = &mockLogsGenerator{}
+
+func (g *mockLogsGenerator) Generate(options *generator.GeneratorOptions) (*generator.GeneratorOutput, error) {
+	records := make([]*logstypes.LogRecord, 0, options.Logs.LogsCount)
+	for i := 0; i < options.Logs.LogsCount; i++ {
+		records = append(records, &logstypes.LogRecord{
+			Timestamp: options.Logs.Timestamp,
+			Fields: []*logstypes.LogField{
+				{Name: "message", Key: "message", Value: "test"},
+			},
+			Line: []byte("test"),
+		})
+	}
+
+	return &generator.GeneratorOutput{Data: logstypes.JoinLines(records), Logs: records}, nil
+}
+
+type mockMetricsGenerator struct{}
+
+var _ generator.Generator = &mockMetricsGenerator{}
+
+func (g *mockMetricsGenerator) Generate(options *generator.GeneratorOptions) (*generator.GeneratorOutput, error) {
+	series := make([]*metrics.Series, 0, options.Metrics.SeriesCount)
+	for i := 0; i < options.Metrics.SeriesCount; i++ {
+		samples := make([]*metrics.Sample, 0, options.Metrics.SamplesPerSeries)
+		for j := 0; j < options.Metrics.SamplesPerSeries; j++ {
+			samples = append(samples, &metrics.Sample{Timestamp: time.Now().UnixMilli(), Value: float64(j)})
+		}
+
+		series = append(series, &metrics.Series{
+			Labels:  []*metrics.LabelPair{{Name: metrics.MetricNameLabel, Value: "test"}},
+			Samples: samples,
+		})
+	}
+
+	return &generator.GeneratorOutput{Metrics: series}, nil
+}
+
+// mockOTLPLogsService is the in-process OTLP gRPC logs receiver.
+type mockOTLPLogsService struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	requests atomic.Int64
+	records  atomic.Int64
+	tenant   atomic.Value
+}
+
+func (s *mockOTLPLogsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok && len(md.Get("x-tenant")) > 0 {
+		s.tenant.Store(md.Get("x-tenant")[0])
+	}
+
+	s.requests.Add(1)
+	for _, rl := range req.GetResourceLogs() {
+		for _, sl := range rl.GetScopeLogs() {
+			s.records.Add(int64(len(sl.GetLogRecords())))
+		}
+	}
+
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+// mockOTLPMetricsService is the in-process OTLP gRPC metrics receiver.
+type mockOTLPMetricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+
+	requests   atomic.Int64
+	dataPoints atomic.Int64
+}
+
+func (s *mockOTLPMetricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	s.requests.Add(1)
+	for _, rm := range req.GetResourceMetrics() {
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, metric := range sm.GetMetrics() {
+				s.dataPoints.Add(int64(len(metric.GetGauge().GetDataPoints())))
+			}
+		}
+	}
+
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func TestGRPCLoader(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	service := &mockOTLPLogsService{}
+	server := grpc.NewServer()
+	collogspb.RegisterLogsServiceServer(server, service)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cfg := &Config{
+		Rate:     20,
+		Workers:  4,
+		Duration: 1 * time.Second,
+		Protocol: ProtocolOTLP,
+		OTLP:     OTLPConfig{}.defaults(),
+		Logs: &LogsGeneratorConfig{
+			RecordsPerRequest: 5,
+		},
+		GRPC: &GRPCConfig{
+			Host:        "127.0.0.1",
+			Port:        listener.Addr().(*net.TCPAddr).Port,
+			Compression: "gzip",
+			Connections: 2,
+			Timeout:     time.Second,
+			Headers: map[string]string{
+				"x-tenant": "o11ybench",
+			},
+		},
+	}
+
+	collector := collector.New()
+	loader, err := New(cfg, &mockLogsGenerator{}, collector)
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	if err := loader.Start(); err != nil {
+		t.Fatalf("failed to start loader: %v", err)
+	}
+
+	if service.requests.Load() == 0 {
+		t.Fatalf("no requests are received by the grpc service")
+	}
+
+	if service.records.Load() != service.requests.Load()*int64(cfg.Logs.RecordsPerRequest) {
+		t.Fatalf("expected '%d' records, but got '%d'", service.requests.Load()*int64(cfg.Logs.RecordsPerRequest), service.records.Load())
+	}
+
+	if tenant, _ := service.tenant.Load().(string); tenant != "o11ybench" {
+		t.Fatalf("expected metadata 'x-tenant: o11ybench', but got '%s'", tenant)
+	}
+
+	if collector.RawBytes() == 0 || collector.WireBytes() == 0 {
+		t.Fatalf("the sent bytes are not recorded: raw '%d', wire '%d'", collector.RawBytes(), collector.WireBytes())
+	}
+}
+
+func TestGRPCLoaderMetrics(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	service := &mockOTLPMetricsService{}
+	server := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(server, service)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cfg := &Config{
+		Rate:     20,
+		Workers:  2,
+		Duration: 1 * time.Second,
+		Protocol: ProtocolOTLP,
+		OTLP:     OTLPConfig{}.defaults(),
+		Metrics: &MetricsGeneratorConfig{
+			SeriesPerRequest: 3,
+			SamplesPerSeries: 2,
+		},
+		GRPC: &GRPCConfig{
+			Host:        "127.0.0.1",
+			Port:        listener.Addr().(*net.TCPAddr).Port,
+			Connections: 1,
+			Timeout:     time.Second,
+		},
+	}
+
+	collector := collector.New()
+	loader, err := New(cfg, &mockMetricsGenerator{}, collector)
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	if err := loader.Start(); err != nil {
+		t.Fatalf("failed to start loader: %v", err)
+	}
+
+	if service.requests.Load() == 0 {
+		t.Fatalf("no requests are received by the grpc service")
+	}
+
+	if service.dataPoints.Load() != service.requests.Load()*6 {
+		t.Fatalf("expected '%d' data points, but got '%d'", service.requests.Load()*6, service.dataPoints.Load())
+	}
+
+	if collector.FailureCount() != 0 {
+		t.Fatalf("expected no failures, but got '%d'", collector.FailureCount())
+	}
+}
@@ -0,0 +1,351 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// httpSender sends the generated data by the HTTP requests.
+type httpSender struct {
+	cfg     *HTTPConfig
+	encoder Encoder
+
+	// client is the client shared by all the workers. It's nil in the connection-per-worker mode.
+	client *http.Client
+
+	// workerClients are the clients of the workers by the worker ID in the connection-per-worker mode.
+	workerClients   map[int]*http.Client
+	workerClientsMu sync.Mutex
+
+	// compressor compresses the payload. It's nil if the compression is not set.
+	compressor *compressor
+
+	// auth provides the authentication header. It's nil if the auth is not set.
+	auth *authProvider
+
+	// response validates the response by the rules in the config. It's nil if the rules are not set.
+	response *responseValidator
+
+	// records is the number of the records in each request, which is compared with the accepted records in the response.
+	records int64
+
+	// templates render the URI and the headers of each request. It's nil if none of them is a template.
+	templates *requestTemplates
+}
+
+var (
+	_ sender         = &httpSender{}
+	_ preparedSender = &httpSender{}
+)
+
+func newHTTPSender(cfg *HTTPConfig, encoder Encoder) (*httpSender, error) {
+	s := &httpSender{cfg: cfg, encoder: encoder, workerClients: make(map[int]*http.Client)}
+
+	// The client of each worker is created on its first request, and the config is checked by creating the shared client anyway.
+	client, err := s.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.transport().ConnectionPerWorker {
+		s.client = client
+	}
+
+	if cfg.Compression != "" {
+		compressor, err := newCompressor(cfg.Compression, cfg.CompressionLevel)
+		if err != nil {
+			return nil, err
+		}
+		s.compressor = compressor
+	}
+
+	if cfg.Auth != nil {
+		auth, err := newAuthProvider(cfg.Auth)
+		if err != nil {
+			return nil, err
+		}
+		s.auth = auth
+	}
+
+	templates, err := newRequestTemplates(cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.templates = templates
+
+	if cfg.Response != nil {
+		response, err := newResponseValidator(cfg.Response)
+		if err != nil {
+			return nil, err
+		}
+		s.response = response
+	}
+
+	return s, nil
+}
+
+func (s *httpSender) send(ctx context.Context, w *worker, output *generator.GeneratorOutput) (*sendResult, error) {
+	p, err := s.prepare(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.sendPrepared(ctx, w, p)
+}
+
+// prepare encodes the generated data by the protocol and compresses it into the body of the request.
+func (s *httpSender) prepare(output *generator.GeneratorOutput) (*preparedPayload, error) {
+	payload, err := s.encoder.Encode(output)
+	if err != nil {
+		return nil, &classifiedError{class: failureClassEncode, err: err}
+	}
+
+	p := &preparedPayload{contentType: payload.ContentType, headers: payload.Headers, rawSize: payload.rawSize()}
+	if s.compressor == nil {
+		p.data = payload.Data
+		return p, nil
+	}
+
+	var buf bytes.Buffer
+	start := time.Now()
+	if err := s.compressor.compress(&buf, payload.Data); err != nil {
+		return nil, &classifiedError{class: failureClassEncode, err: err}
+	}
+	p.compressTime = time.Since(start)
+	p.data = buf.Bytes()
+
+	return p, nil
+}
+
+// sendPrepared sends the prepared body. The URI and the headers are still rendered for each request.
+func (s *httpSender) sendPrepared(ctx context.Context, w *worker, p *preparedPayload) (*sendResult, error) {
+	client, err := s.clientOf(w)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := s.makeHTTPRequest(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+	result := &sendResult{rawBytes: p.rawSize, wireBytes: int64(len(p.data)), compressTime: p.compressTime}
+
+	// The connection of the request is traced to count the new and the reused connections.
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				result.reusedConnections++
+			} else {
+				result.newConnections++
+			}
+		},
+	}))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if result.reusedConnections > 0 && (errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)) {
+			return nil, &staleConnectionError{err: err}
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The data is sent once the response is received, even if the target rejects it.
+	return result, s.checkResponse(req, resp)
+}
+
+func (s *httpSender) close() error {
+	if s.client != nil {
+		s.client.CloseIdleConnections()
+	}
+
+	s.workerClientsMu.Lock()
+	for _, client := range s.workerClients {
+		client.CloseIdleConnections()
+	}
+	s.workerClientsMu.Unlock()
+
+	if s.compressor != nil {
+		s.compressor.close()
+	}
+	if s.auth != nil {
+		s.auth.close()
+	}
+
+	return nil
+}
+
+func (s *httpSender) checkResponse(req *http.Request, resp *http.Response) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &classifiedError{class: failureClassBodyRead, err: err}
+	}
+
+	checker, hasChecker := s.encoder.(responseChecker)
+	switch {
+	case s.response != nil && len(s.response.statusCodes) > 0:
+		// The status codes in the config replace the status check of the protocol, but the body of the protocol is still checked.
+		if !s.response.acceptsStatusCode(resp.StatusCode) {
+			err = &statusCodeError{code: resp.StatusCode, body: body}
+		} else if checker, ok := s.encoder.(bodyChecker); ok {
+			err = checker.checkBody(body)
+		}
+	case hasChecker:
+		err = checker.checkResponse(resp.StatusCode, body)
+	case resp.StatusCode < 200 || resp.StatusCode >= 300:
+		err = &statusCodeError{code: resp.StatusCode, body: body}
+	}
+
+	if err == nil && s.response != nil {
+		err = s.response.checkBody(body, s.records)
+	}
+	if err == nil {
+		return nil
+	}
+
+	var codeErr *statusCodeError
+	if errors.As(err, &codeErr) {
+		codeErr.retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+
+	return fmt.Errorf("request '%s' failed: %w", req.URL, err)
+}
+
+func (s *httpSender) makeHTTPRequest(ctx context.Context, p *preparedPayload) (*http.Request, error) {
+	// The values of the request-level tokens are shared by the URI and the headers of the request.
+	var (
+		tokens map[string]any
+		err    error
+	)
+	if s.templates != nil {
+		if tokens, err = s.templates.generate(); err != nil {
+			return nil, &classifiedError{class: failureClassGenerate, err: err}
+		}
+	}
+
+	requestURL, err := s.constructURL(tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body is read by a new reader, so the pooled payload can be sent by the workers concurrently.
+	req, err := http.NewRequestWithContext(ctx, strings.ToUpper(s.cfg.Method), requestURL, bytes.NewReader(p.data))
+	if err != nil {
+		return nil, err
+	}
+
+	if p.contentType != "" {
+		req.Header.Set("Content-Type", p.contentType)
+	}
+
+	for k, v := range p.headers {
+		req.Header.Set(k, v)
+	}
+
+	for k, v := range s.cfg.Headers {
+		if s.templates != nil && s.templates.headers[k] != nil {
+			if v, err = render(s.templates.headers[k], tokens); err != nil {
+				return nil, &classifiedError{class: failureClassGenerate, err: err}
+			}
+		}
+		req.Header.Set(k, v)
+	}
+
+	if s.auth != nil {
+		req.Header.Set(s.auth.header())
+	}
+
+	if s.compressor != nil {
+		req.Header.Set("Content-Encoding", s.compressor.contentEncoding())
+	}
+
+	return req, nil
+}
+
+// clientOf returns the client of the worker. It's the shared client unless the connection-per-worker mode is enabled.
+func (s *httpSender) clientOf(w *worker) (*http.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+
+	s.workerClientsMu.Lock()
+	defer s.workerClientsMu.Unlock()
+
+	if client, ok := s.workerClients[w.id]; ok {
+		return client, nil
+	}
+
+	client, err := s.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	s.workerClients[w.id] = client
+
+	return client, nil
+}
+
+// httpClient creates a new client with its own transport, so the connections are pooled by the client.
+func (s *httpSender) httpClient() (*http.Client, error) {
+	tc := s.cfg.transport()
+
+	dialer := &net.Dialer{Timeout: tc.DialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		MaxIdleConns:          tc.MaxIdleConns,
+		MaxIdleConnsPerHost:   tc.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       tc.MaxConnsPerHost,
+		IdleConnTimeout:       tc.IdleConnTimeout,
+		DisableKeepAlives:     tc.DisableKeepAlives,
+		TLSHandshakeTimeout:   tc.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: s.cfg.ResponseHeaderTimeout,
+	}
+
+	if tc.HTTP2 {
+		protocols := new(http.Protocols)
+		if s.cfg.scheme() == "https" {
+			protocols.SetHTTP2(true)
+		} else {
+			protocols.SetUnencryptedHTTP2(true)
+		}
+		transport.Protocols = protocols
+	}
+
+	if s.cfg.scheme() == "https" {
+		tlsCfg := s.cfg.TLS
+		if tlsCfg == nil {
+			tlsCfg = &TLSConfig{}
+		}
+
+		tlsConfig, err := tlsCfg.tlsConfig(s.cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{Transport: transport, Timeout: tc.Timeout}, nil
+}
+
+// constructURL returns the URL of the request. The URI is rendered by the token values of the request if it's a template.
+func (s *httpSender) constructURL(tokens map[string]any) (string, error) {
+	uri := s.cfg.URI
+	if s.templates != nil && s.templates.uri != nil {
+		var err error
+		if uri, err = render(s.templates.uri, tokens); err != nil {
+			return "", &classifiedError{class: failureClassGenerate, err: err}
+		}
+	}
+
+	return fmt.Sprintf("%s://%s%s", s.cfg.scheme(), net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)), uri), nil
+}
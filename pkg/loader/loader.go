@@ -1,14 +1,11 @@
 package loader
 
 import (
-	"bytes"
-	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/signal"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -16,192 +13,343 @@ import (
 	"github.com/zyy17/o11ybench/pkg/collector"
 	"github.com/zyy17/o11ybench/pkg/generator"
 	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
 )
 
 type Loader struct {
 	cfg       *Config
 	generator generator.Generator
 	collector *collector.Collector
+	sender    sender
+
+	// retrier retries the failed requests. It's nil if the retry is not set.
+	retrier *retrier
+
+	// pool is the pool of the pre-generated payloads. It's nil if the pool is not set.
+	pool *payloadPool
 }
 
 func New(cfg *Config, generator generator.Generator, collector *collector.Collector) (*Loader, error) {
-	return &Loader{cfg: cfg, generator: generator, collector: collector}, nil
+	encoder, err := NewEncoder(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	sender, err := newSender(cfg, encoder)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Loader{cfg: cfg, generator: generator, collector: collector, sender: sender}
+	if cfg.Retry != nil {
+		l.retrier = newRetrier(cfg.Retry)
+	}
+
+	return l, nil
 }
 
 func (l *Loader) Start() error {
 	var (
-		// infinite is true if the loader is running infinitely.
-		infinite bool
-
-		// stopTime is the time when the loader will stop if it's not infinite.
+		// stopTime is the time when the loader will stop. It's zero if the loader is running infinitely.
 		stopTime time.Time
 
-		// requestsNum is the number of requests for each worker(goroutine).
-		requestsNum = l.cfg.Rate / l.cfg.Workers
+		// requests is the queue of the scheduled requests. The workers take the requests from the queue.
+		requests = make(chan *request, l.cfg.Workers)
 
 		// wg is the wait group for the workers.
 		wg sync.WaitGroup
 	)
 
-	if l.cfg.Duration == 0 {
-		infinite = true
-	} else {
+	// The payloads of the pool are generated before the run, so the time is not counted in the duration of the test.
+	if l.cfg.Pool != nil {
+		start := time.Now()
+		pool, err := newPayloadPool(l.cfg.Pool, l.generator, l.generatorOptions, l.sender)
+		if err != nil {
+			return err
+		}
+		defer pool.close()
+
+		l.pool = pool
+		l.collector.SetPool(l.cfg.Pool.Size, time.Since(start))
+	}
+
+	if l.cfg.Duration > 0 {
 		stopTime = time.Now().Add(l.cfg.Duration)
 	}
 
+	if l.cfg.Profile != nil {
+		stopTime = time.Now().Add(l.cfg.Profile.duration())
+	}
+
 	// Start the collector.
 	l.collector.Start()
 
-	// Setup the signal handling to ensure we print stats even if terminated.
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	// Setup the signal handling to stop the scheduling, so the stats are still printed and reported if terminated.
+	// The second signal exits immediately if the in-flight requests are stuck.
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(signals)
+		close(signals)
+	}()
+
+	interrupted := make(chan struct{})
 	go func() {
-		<-stop
-		fmt.Println("Received interrupt or termination signal, printing stats before exit...")
-		l.collector.Stop()
-		l.collector.Print()
-		os.Exit(0)
+		if _, ok := <-signals; !ok {
+			return
+		}
+		fmt.Println("Received interrupt or termination signal, waiting for the in-flight requests before printing stats...")
+		close(interrupted)
+
+		if _, ok := <-signals; ok {
+			os.Exit(1)
+		}
 	}()
 
 	for i := 0; i < l.cfg.Workers; i++ {
 		wg.Add(1)
-		w := &worker{id: i, requestsNum: requestsNum, infinite: infinite, stopTime: stopTime}
+		w := &worker{id: i}
 		go func() {
 			defer wg.Done()
-			l.workerLoop(w)
+			l.workerLoop(w, requests, stopTime, interrupted)
 		}()
 	}
 
+	l.schedule(requests, stopTime, interrupted)
 	wg.Wait()
 
 	// Stop the collector.
 	l.collector.Stop()
 
-	return nil
+	return l.sender.close()
 }
 
 type worker struct {
-	id          int
-	requestsNum int
-	infinite    bool
-	stopTime    time.Time
+	id int
 }
 
-func (l *Loader) workerLoop(w *worker) {
+// request is a scheduled request.
+type request struct {
+	// intended is the intended start time of the request.
+	intended time.Time
+
+	// stats is the Collector that the result of the request is recorded in. It's the Collector of the stage if the profile is set.
+	stats *collector.Collector
+}
+
+// schedule puts the scheduled requests into the queue until the stop time. It closes the queue when it returns.
+// If all the workers are busy, it blocks on the queue but the intended start times are kept, so the send lag of the requests will grow.
+// It also returns once the interrupted channel is closed.
+func (l *Loader) schedule(requests chan<- *request, stopTime time.Time, interrupted <-chan struct{}) {
+	defer close(requests)
+
+	var stages []*collector.Collector
+	if l.cfg.Profile != nil {
+		for i, stage := range l.cfg.Profile.Stages {
+			stages = append(stages, l.collector.AddStage(stage.name(i)))
+		}
+	}
+
+	// current is the index of the running stage. The stage is stopped when the profile moves to the next stage or the scheduling returns,
+	// so the duration of the stage is measured even if the test is interrupted.
+	current := -1
+	defer func() {
+		if current >= 0 {
+			stages[current].Stop()
+		}
+	}()
+
+	l.collector.SetTargetRate(l.cfg.Rate)
+
+	start := time.Now()
+	scheduler := newScheduler(start, l.cfg.Rate, l.cfg.Profile, l.cfg.Arrival)
+	if len(stages) > 0 {
+		stages[0].Start()
+		current = 0
+	}
+
 	for {
-		// Check if the worker should stop.
-		if !w.infinite && time.Now().After(w.stopTime) {
+		intended := scheduler.next()
+		if !stopTime.IsZero() && !intended.Before(stopTime) {
+			// Wait until the stop time, so the duration of the test is not shortened when the rate is low at the end.
+			sleepUntil(stopTime, interrupted)
 			return
 		}
 
-		hc, err := l.httpClient()
-		if err != nil {
-			panic(err)
+		stats, next := l.collector, -1
+		if l.cfg.Profile != nil {
+			if i, _ := l.cfg.Profile.stageAt(intended.Sub(start)); i >= 0 {
+				stats, next = stages[i], i
+			}
+			l.collector.SetTargetRate(l.cfg.Profile.rateAt(intended.Sub(start)))
 		}
 
-		start := time.Now()
-		for i := 0; i < w.requestsNum; i++ {
-			if err := l.doRequest(hc); err != nil {
-				l.collector.IncFailureCount(1)
-				fmt.Printf("worker [%d] failed to make request: %v\n", w.id, err)
-			} else {
-				l.collector.IncSuccessCount(1)
-				l.collector.IncRecordsCount(int64(l.cfg.Logs.RecordsPerRequest))
-			}
+		if !sleepUntil(intended, interrupted) {
+			return
+		}
 
-			// Check if the worker should stop.
-			if !w.infinite && time.Now().After(w.stopTime) {
-				return
-			}
+		// The profile moves to the next stage when the first request of the stage is due.
+		if next > current {
+			stages[current].Stop()
+			stages[next].Start()
+			current = next
 		}
-		elapsed := time.Since(start)
 
-		// sleep if the requests are not enough to reach the rate.
-		if elapsed < time.Second {
-			time.Sleep(time.Second - elapsed)
+		select {
+		case requests <- &request{intended: intended, stats: stats}:
+		case <-interrupted:
+			return
 		}
 	}
 }
 
-func (l *Loader) doRequest(hc *http.Client) error {
-	req, err := l.makeHTTPRequest()
-	if err != nil {
-		return err
+// sleepUntil sleeps until the time t. It returns false if it's interrupted.
+func sleepUntil(t time.Time, interrupted <-chan struct{}) bool {
+	wait := time.Until(t)
+	if wait <= 0 {
+		select {
+		case <-interrupted:
+			return false
+		default:
+			return true
+		}
 	}
 
-	resp, err := hc.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	select {
+	case <-timer.C:
+		return true
+	case <-interrupted:
+		return false
 	}
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("request '%s' failed with status code '%d' and body '%s'", req.URL, resp.StatusCode, string(body))
-	}
+// workerLoop makes the requests from the queue. The backoff of the retries is stopped once the interrupted channel is closed,
+// and the retries are given up if they would wait past the stop time.
+func (l *Loader) workerLoop(w *worker, requests <-chan *request, stopTime time.Time, interrupted <-chan struct{}) {
+	for req := range requests {
+		stats := req.stats
+
+		// The send lag is how late the request is made compared with its intended start time.
+		stats.ObserveSendLag(time.Since(req.intended))
+
+		stats.IncInFlight(1)
+		result, err := l.doRequest(w, stats, stopTime, interrupted)
+		stats.IncInFlight(-1)
+		if result != nil {
+			stats.IncBytes(result.rawBytes, result.wireBytes)
+			if result.compressTime > 0 {
+				stats.ObserveCompressionTime(result.compressTime)
+			}
+			stats.IncConnections(result.newConnections, result.reusedConnections)
+		}
 
-	return nil
-}
+		// The failures are classified and summarized by the collector instead of being printed one by one.
+		var partialErr *partialFailureError
+		if errors.As(err, &partialErr) {
+			// The request is accepted but part of the records are rejected.
+			stats.RecordFailure(err)
+			stats.IncRecordsCount(l.cfg.recordsPerRequest() - partialErr.rejected)
+			stats.IncRejectedRecordsCount(partialErr.rejected)
+		} else if err != nil {
+			stats.RecordFailure(err)
+		} else {
+			stats.IncSuccessCount(1)
+			stats.IncRecordsCount(l.cfg.recordsPerRequest())
+		}
 
-func (l *Loader) makeHTTPRequest() (*http.Request, error) {
-	// Generates the payload for the request.
-	output, err := l.generator.Generate(l.generatorOptions())
-	if err != nil {
-		return nil, err
+		// The latency is measured from the intended start time, so the waiting time for a free worker is included.
+		stats.ObserveLatency(time.Since(req.intended))
 	}
+}
 
-	requestURL, err := l.constructURL()
+// doRequest generates the payload and sends it. The same payload is sent again if the request is retried,
+// and the result is the total size of the data sent by all the attempts.
+func (l *Loader) doRequest(w *worker, stats *collector.Collector, stopTime time.Time, interrupted <-chan struct{}) (*sendResult, error) {
+	// Generates the payload for the request, or takes it from the pool.
+	start := time.Now()
+	output, prepared, err := l.nextPayload()
+	stats.ObserveGenerateTime(time.Since(start))
 	if err != nil {
-		return nil, err
+		return nil, &classifiedError{class: failureClassGenerate, err: err}
 	}
 
-	var buf bytes.Buffer
-	if l.cfg.HTTP.Compression == "gzip" {
-		// Compress the payload using gzip.
-		writer := gzip.NewWriter(&buf)
-		if _, err := writer.Write(output.Data); err != nil {
-			return nil, err
+	send := func() (*sendResult, error) {
+		start := time.Now()
+		defer func() { stats.ObserveSendTime(time.Since(start)) }()
+
+		if prepared != nil {
+			return l.sender.(preparedSender).sendPrepared(context.Background(), w, prepared)
 		}
-		writer.Close()
-	} else {
-		buf.Write(output.Data)
+		return l.sender.send(context.Background(), w, output)
 	}
 
-	req, err := http.NewRequest(strings.ToUpper(l.cfg.HTTP.Method), requestURL, &buf)
-	if err != nil {
-		return nil, err
+	result, err := send()
+	if l.retrier == nil {
+		return result, err
 	}
 
-	for k, v := range l.cfg.HTTP.Headers {
-		req.Header.Set(k, v)
-	}
+	total := &sendResult{}
+	total.add(result)
+	for attempt := 1; err != nil; attempt++ {
+		retryable, retryAfter := l.retrier.retryable(err)
+		if !retryable {
+			break
+		}
 
-	if l.cfg.HTTP.Compression == "gzip" {
-		req.Header.Set("Content-Encoding", "gzip")
-	}
+		if attempt >= l.retrier.cfg.MaxAttempts {
+			stats.IncExhaustedRetriesCount(1)
+			break
+		}
 
-	return req, nil
-}
+		// The retry is abandoned if the backoff would end after the stop time, so the test is not extended by the retries.
+		// It's counted apart from the exhausted retries because the request could still succeed with the remaining attempts.
+		next := time.Now().Add(l.retrier.backoff(attempt, retryAfter))
+		if !stopTime.IsZero() && next.After(stopTime) {
+			stats.IncAbandonedRetriesCount(1)
+			break
+		}
 
-func (l *Loader) httpClient() (*http.Client, error) {
-	client := http.DefaultClient
+		if !sleepUntil(next, interrupted) {
+			stats.IncAbandonedRetriesCount(1)
+			break
+		}
 
-	client.Transport = &http.Transport{
-		ResponseHeaderTimeout: l.cfg.HTTP.ResponseHeaderTimeout,
+		stats.IncRetriesCount(1)
+		result, err = send()
+		total.add(result)
+		if err == nil {
+			stats.IncRetriedSuccessCount(1)
+		}
 	}
 
-	return client, nil
+	return total, err
 }
 
-func (l *Loader) constructURL() (string, error) {
-	return fmt.Sprintf("http://%s:%d%s", l.cfg.HTTP.Host, l.cfg.HTTP.Port, l.cfg.HTTP.URI), nil
+// nextPayload returns the payload of the request. It's taken from the pool if the pool is set, otherwise it's generated.
+// The payload is either the generated data or the payload prepared by the sender.
+func (l *Loader) nextPayload() (*generator.GeneratorOutput, *preparedPayload, error) {
+	if l.pool != nil {
+		return l.pool.get()
+	}
+
+	output, err := l.generator.Generate(l.generatorOptions())
+	return output, nil, err
 }
 
 func (l *Loader) generatorOptions() *generator.GeneratorOptions {
+	if l.cfg.Metrics != nil {
+		return &generator.GeneratorOptions{
+			Metrics: &metrics.GeneratorOptions{
+				SeriesCount:      l.cfg.Metrics.SeriesPerRequest,
+				SamplesPerSeries: l.cfg.Metrics.SamplesPerSeries,
+				Timestamp:        time.Now(),
+			},
+		}
+	}
+
 	return &generator.GeneratorOptions{
 		Logs: &logstypes.GeneratorOptions{
 			LogsCount: l.cfg.Logs.RecordsPerRequest,
@@ -3,7 +3,9 @@ package loader
 import (
 	"fmt"
 	"math"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -25,28 +27,34 @@ func (g *mockGenerator) Generate(options *generator.GeneratorOptions) (*generato
 type mockTargetService struct {
 	port     int
 	endpoint string
-	rate     int
+	rate     float64
 }
 
-func (s *mockTargetService) Start() error {
+// Start starts the mock target service. The caller closes the returned server when the test is done.
+func (s *mockTargetService) Start() (*http.Server, error) {
 	// Create a new http server and always return 200 OK for the endpoint.
 	mux := http.NewServeMux()
 	mux.HandleFunc(s.endpoint, func(w http.ResponseWriter, r *http.Request) {
 		if s.rate > 0 {
-			processTime := time.Second / time.Duration(s.rate)
+			processTime := time.Duration(float64(time.Second) / s.rate)
 			time.Sleep(processTime)
 		}
 
 		w.WriteHeader(http.StatusOK)
 	})
 
-	// Choose a random port.
+	// Listen before serving to make sure the service is ready when Start returns.
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return nil, err
+	}
+
 	server := &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
 		Handler: mux,
 	}
+	go server.Serve(listener)
 
-	return server.ListenAndServe()
+	return server, nil
 }
 
 func TestLoader(t *testing.T) {
@@ -57,7 +65,7 @@ func TestLoader(t *testing.T) {
 		Logs: &LogsGeneratorConfig{
 			RecordsPerRequest: 10,
 		},
-		HTTP: HTTPConfig{
+		HTTP: &HTTPConfig{
 			Host:        "localhost",
 			Port:        int(utils.RandomNumber(20000, 40000)),
 			URI:         "/api/load",
@@ -81,17 +89,227 @@ func TestLoader(t *testing.T) {
 		endpoint: cfg.HTTP.URI,
 		rate:     cfg.Rate,
 	}
-	go mockTargetService.Start()
+	server, err := mockTargetService.Start()
+	if err != nil {
+		t.Fatalf("failed to start mock target service: %v", err)
+	}
+	defer server.Close()
 
 	// Start the loader.
 	loader.Start()
 
 	delta := 1.0
 	if math.Abs(collector.Rate()-float64(cfg.Rate)) > delta {
-		t.Fatalf("actual rate: '%f', expected rate: '%d', delta: '%f'", collector.Rate(), cfg.Rate, delta)
+		t.Fatalf("actual rate: '%f', expected rate: '%f', delta: '%f'", collector.Rate(), cfg.Rate, delta)
 	}
 
 	if math.Abs(float64(collector.Duration().Seconds())-cfg.Duration.Seconds()) > delta {
 		t.Fatalf("actual duration: '%s', expected duration: '%s', delta: '%f'", collector.Duration(), cfg.Duration, delta)
 	}
+
+	// The mock generator always generates `test`, and it's compressed by gzip.
+	if collector.RawBytes() != 4*collector.SuccessCount() {
+		t.Fatalf("actual raw bytes: '%d', expected raw bytes: '%d'", collector.RawBytes(), 4*collector.SuccessCount())
+	}
+
+	if collector.WireBytes() <= collector.RawBytes() {
+		t.Fatalf("the wire bytes '%d' should include the gzip header and be greater than the raw bytes '%d'", collector.WireBytes(), collector.RawBytes())
+	}
+}
+
+func TestLoaderOpenLoop(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64
+		workers     int
+		targetRate  float64
+		wantSuccess int64
+	}{
+		// The remainder of the rate is not dropped by the workers.
+		{name: "rate is not divisible by workers", rate: 5, workers: 2, wantSuccess: 10},
+		{name: "fractional rate", rate: 1.5, workers: 2, wantSuccess: 3},
+
+		// The target can only handle 5 requests per second with 1 worker, so the requests wait for the worker.
+		{name: "slow target", rate: 10, workers: 1, targetRate: 5, wantSuccess: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Rate:     tt.rate,
+				Workers:  tt.workers,
+				Duration: 2 * time.Second,
+				Logs: &LogsGeneratorConfig{
+					RecordsPerRequest: 1,
+				},
+				HTTP: &HTTPConfig{
+					Host:   "localhost",
+					Port:   int(utils.RandomNumber(20000, 40000)),
+					URI:    "/api/load",
+					Method: "POST",
+				},
+			}
+
+			mockTargetService := &mockTargetService{
+				port:     cfg.HTTP.Port,
+				endpoint: cfg.HTTP.URI,
+				rate:     tt.targetRate,
+			}
+			server, err := mockTargetService.Start()
+			if err != nil {
+				t.Fatalf("failed to start mock target service: %v", err)
+			}
+			defer server.Close()
+
+			collector := collector.New()
+			loader, err := New(cfg, &mockGenerator{}, collector)
+			if err != nil {
+				t.Fatalf("failed to create loader: %v", err)
+			}
+
+			if err := loader.Start(); err != nil {
+				t.Fatalf("failed to start loader: %v", err)
+			}
+
+			if collector.SuccessCount() != tt.wantSuccess {
+				t.Fatalf("actual success: '%d', expected success: '%d'", collector.SuccessCount(), tt.wantSuccess)
+			}
+
+			if tt.targetRate > 0 {
+				// The last request is scheduled at 1.9s but can only be sent after the previous 19 requests are done at about 3.8s.
+				if collector.MaxSendLag() < time.Second {
+					t.Fatalf("the send lag is not recorded: '%s'", collector.MaxSendLag())
+				}
+
+				serviceTime := time.Duration(float64(time.Second) / tt.targetRate)
+				if collector.MeanLatency() < 2*serviceTime {
+					t.Fatalf("the latency is not measured from the intended start: '%s'", collector.MeanLatency())
+				}
+			}
+		})
+	}
+}
+
+func TestLoaderProfile(t *testing.T) {
+	cfg := &Config{
+		Workers: 2,
+		Profile: &ProfileConfig{
+			Stages: []*StageConfig{
+				{Name: "warmup", Type: StageTypeConstant, Duration: time.Second, Rate: 5},
+				{Type: StageTypeConstant, Duration: time.Second, Rate: 10},
+			},
+		},
+		Logs: &LogsGeneratorConfig{
+			RecordsPerRequest: 1,
+		},
+		HTTP: &HTTPConfig{
+			Host:   "localhost",
+			Port:   int(utils.RandomNumber(20000, 40000)),
+			URI:    "/api/load",
+			Method: "POST",
+		},
+	}
+
+	mockTargetService := &mockTargetService{port: cfg.HTTP.Port, endpoint: cfg.HTTP.URI}
+	server, err := mockTargetService.Start()
+	if err != nil {
+		t.Fatalf("failed to start mock target service: %v", err)
+	}
+	defer server.Close()
+
+	collector := collector.New()
+	loader, err := New(cfg, &mockGenerator{}, collector)
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	if err := loader.Start(); err != nil {
+		t.Fatalf("failed to start loader: %v", err)
+	}
+
+	stages := collector.Stages()
+	if len(stages) != 2 {
+		t.Fatalf("unexpected stages: '%d'", len(stages))
+	}
+
+	expected := []struct {
+		name    string
+		success int64
+	}{
+		{name: "warmup", success: 5},
+		{name: "stage-1", success: 10},
+	}
+	for i, stage := range stages {
+		if stage.Name() != expected[i].name || stage.SuccessCount() != expected[i].success {
+			t.Fatalf("unexpected stage %d: '%s' with '%d' success, expected: '%s' with '%d' success", i, stage.Name(), stage.SuccessCount(), expected[i].name, expected[i].success)
+		}
+
+		// The duration of the stage is measured from the time the profile moves into it to the time it moves out.
+		if delta := math.Abs(stage.Duration().Seconds() - cfg.Profile.Stages[i].Duration.Seconds()); delta > 0.2 {
+			t.Fatalf("actual duration of stage %d: '%s', expected duration: '%s'", i, stage.Duration(), cfg.Profile.Stages[i].Duration)
+		}
+	}
+
+	if stages[0].StopTime().After(stages[1].StartTime()) {
+		t.Fatalf("the stage 0 stops at '%s' after the stage 1 starts at '%s'", stages[0].StopTime(), stages[1].StartTime())
+	}
+
+	if collector.SuccessCount() != 15 {
+		t.Fatalf("actual success: '%d', expected success: '15'", collector.SuccessCount())
+	}
+}
+
+func TestLoaderFailureClasses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	// Listen and close to get a port that refuses the connections.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	refusedPort := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	tests := []struct {
+		port     int
+		expected string
+	}{
+		{port: server.Listener.Addr().(*net.TCPAddr).Port, expected: "HTTP 503"},
+		{port: refusedPort, expected: collector.FailureClassConnectionRefused},
+	}
+
+	for _, tt := range tests {
+		cfg := &Config{
+			Rate:     10,
+			Workers:  2,
+			Duration: 500 * time.Millisecond,
+			Logs: &LogsGeneratorConfig{
+				RecordsPerRequest: 1,
+			},
+			HTTP: &HTTPConfig{
+				Host:   "127.0.0.1",
+				Port:   tt.port,
+				URI:    "/api/load",
+				Method: "POST",
+			},
+		}
+
+		collector := collector.New()
+		loader, err := New(cfg, &mockGenerator{}, collector)
+		if err != nil {
+			t.Fatalf("failed to create loader: %v", err)
+		}
+
+		if err := loader.Start(); err != nil {
+			t.Fatalf("failed to start loader: %v", err)
+		}
+
+		failures := collector.Failures()
+		if len(failures) != 1 || failures[0].Class != tt.expected || failures[0].Count != 5 {
+			t.Fatalf("unexpected failures: '%v', expected 5 failures of '%s'", failures, tt.expected)
+		}
+	}
 }
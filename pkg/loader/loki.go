@@ -0,0 +1,261 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+const (
+	// ContentTypeJSON is the content type of the JSON payload.
+	ContentTypeJSON = "application/json"
+)
+
+// lokiEncoder encodes the generated logs into the Loki `PushRequest` streams.
+type lokiEncoder struct {
+	cfg *LokiConfig
+
+	// limiters limits the distinct values of each label token. The key is the token name.
+	limiters map[string]*labelLimiter
+}
+
+var (
+	_ Encoder         = &lokiEncoder{}
+	_ responseChecker = &lokiEncoder{}
+)
+
+// lokiStream is a stream of the Loki push request that has the unique labels.
+type lokiStream struct {
+	labels  map[string]string
+	entries []*lokiEntry
+}
+
+type lokiEntry struct {
+	timestampNano int64
+	line          string
+}
+
+func newLokiEncoder(cfg *LokiConfig) *lokiEncoder {
+	e := &lokiEncoder{cfg: cfg, limiters: make(map[string]*labelLimiter)}
+
+	if cfg.MaxLabelValues > 0 {
+		for _, token := range cfg.LabelTokens {
+			e.limiters[token] = &labelLimiter{max: cfg.MaxLabelValues, seen: make(map[string]struct{})}
+		}
+	}
+
+	return e
+}
+
+func (e *lokiEncoder) Encode(output *generator.GeneratorOutput) (*Payload, error) {
+	streams, err := e.streams(output)
+	if err != nil {
+		return nil, err
+	}
+
+	if e.cfg.Format == LokiFormatJSON {
+		data, err := e.encodeJSON(streams)
+		if err != nil {
+			return nil, err
+		}
+
+		return &Payload{Data: data, ContentType: ContentTypeJSON}, nil
+	}
+
+	data := e.encodeProtobuf(streams)
+	return &Payload{Data: snappy.Encode(nil, data), ContentType: ContentTypeProtobuf, RawSize: len(data)}, nil
+}
+
+// checkResponse accepts both `200 OK` and `204 No Content` since Loki responds `204 No Content` for the successful push.
+func (e *lokiEncoder) checkResponse(statusCode int, body []byte) error {
+	if statusCode != http.StatusOK && statusCode != http.StatusNoContent {
+		return &statusCodeError{code: statusCode, body: body}
+	}
+
+	return nil
+}
+
+// streams groups the generated logs into the streams by the labels.
+func (e *lokiEncoder) streams(output *generator.GeneratorOutput) ([]*lokiStream, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for the loki protocol")
+	}
+
+	var (
+		streams = make([]*lokiStream, 0)
+		index   = make(map[string]*lokiStream)
+	)
+	for _, log := range output.Logs {
+		labels := make(map[string]string, len(e.cfg.Labels)+len(e.cfg.LabelTokens))
+		for k, v := range e.cfg.Labels {
+			labels[sanitizeLabelName(k)] = v
+		}
+
+		for _, token := range e.cfg.LabelTokens {
+			field := log.Field(token)
+			if field == nil {
+				return nil, fmt.Errorf("label token '%s' is not found in the generated logs", token)
+			}
+
+			value := fmt.Sprintf("%v", field.Value)
+			if limiter, ok := e.limiters[token]; ok {
+				value = limiter.limit(value)
+			}
+			labels[sanitizeLabelName(field.Key)] = value
+		}
+
+		key := lokiLabelsString(labels)
+		stream, ok := index[key]
+		if !ok {
+			stream = &lokiStream{labels: labels}
+			index[key] = stream
+			streams = append(streams, stream)
+		}
+
+		stream.entries = append(stream.entries, &lokiEntry{
+			timestampNano: log.Timestamp.UnixNano(),
+			line:          string(log.Line),
+		})
+	}
+
+	return streams, nil
+}
+
+// encodeProtobuf encodes the streams into the Loki `PushRequest` protobuf message:
+//
+//	message PushRequest { repeated Stream streams = 1; }
+//	message Stream { string labels = 1; repeated Entry entries = 2; }
+//	message Entry { google.protobuf.Timestamp timestamp = 1; string line = 2; }
+func (e *lokiEncoder) encodeProtobuf(streams []*lokiStream) []byte {
+	var request []byte
+	for _, stream := range streams {
+		var s []byte
+		s = protowire.AppendTag(s, 1, protowire.BytesType)
+		s = protowire.AppendString(s, lokiLabelsString(stream.labels))
+
+		for _, entry := range stream.entries {
+			var ts []byte
+			ts = protowire.AppendTag(ts, 1, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(entry.timestampNano/1e9))
+			ts = protowire.AppendTag(ts, 2, protowire.VarintType)
+			ts = protowire.AppendVarint(ts, uint64(entry.timestampNano%1e9))
+
+			var en []byte
+			en = protowire.AppendTag(en, 1, protowire.BytesType)
+			en = protowire.AppendBytes(en, ts)
+			en = protowire.AppendTag(en, 2, protowire.BytesType)
+			en = protowire.AppendString(en, entry.line)
+
+			s = protowire.AppendTag(s, 2, protowire.BytesType)
+			s = protowire.AppendBytes(s, en)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, s)
+	}
+
+	return request
+}
+
+// encodeJSON encodes the streams into the JSON format of the Loki push API.
+func (e *lokiEncoder) encodeJSON(streams []*lokiStream) ([]byte, error) {
+	type jsonStream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+
+	request := struct {
+		Streams []jsonStream `json:"streams"`
+	}{
+		Streams: make([]jsonStream, 0, len(streams)),
+	}
+
+	for _, stream := range streams {
+		values := make([][2]string, 0, len(stream.entries))
+		for _, entry := range stream.entries {
+			values = append(values, [2]string{strconv.FormatInt(entry.timestampNano, 10), entry.line})
+		}
+		request.Streams = append(request.Streams, jsonStream{Stream: stream.labels, Values: values})
+	}
+
+	return json.Marshal(request)
+}
+
+// lokiLabelsString returns the labels in the Prometheus format, for example, `{job="o11ybench", level="info"}`.
+func lokiLabelsString(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(labels[k]))
+	}
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+// sanitizeLabelName replaces the invalid characters of the label name with `_`. The valid label name matches `[a-zA-Z_][a-zA-Z0-9_]*`,
+// so the name that starts with a digit is prefixed with `_`.
+func sanitizeLabelName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		return "_" + name
+	}
+
+	return name
+}
+
+// labelLimiter limits the distinct values of a label. The first `max` distinct values are kept,
+// and the other values are mapped to one of the kept values by the hash.
+type labelLimiter struct {
+	mu     sync.Mutex
+	max    int
+	values []string
+	seen   map[string]struct{}
+}
+
+func (l *labelLimiter) limit(value string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.seen[value]; ok {
+		return value
+	}
+
+	if len(l.values) < l.max {
+		l.seen[value] = struct{}{}
+		l.values = append(l.values, value)
+		return value
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(value))
+
+	return l.values[h.Sum32()%uint32(len(l.values))]
+}
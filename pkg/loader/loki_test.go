@@ -0,0 +1,169 @@
+package loader
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+func testLokiOutput(levels ...string) *generator.GeneratorOutput {
+	timestamp, _ := time.Parse(time.RFC3339, "2025-03-23T00:00:00Z")
+
+	output := &generator.GeneratorOutput{}
+	for _, level := range levels {
+		output.Logs = append(output.Logs, &logstypes.LogRecord{
+			Timestamp: timestamp,
+			Fields: []*logstypes.LogField{
+				{Name: "level", Key: "log.level", Value: level},
+			},
+			Line: []byte("level=" + level),
+		})
+	}
+
+	return output
+}
+
+func TestLokiEncoderJSON(t *testing.T) {
+	encoder := newLokiEncoder(&LokiConfig{
+		Format:      LokiFormatJSON,
+		Labels:      map[string]string{"job": "o11ybench"},
+		LabelTokens: []string{"level"},
+	})
+
+	payload, err := encoder.Encode(testLokiOutput("info", "error", "info"))
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	var request struct {
+		Streams []struct {
+			Stream map[string]string `json:"stream"`
+			Values [][2]string       `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(payload.Data, &request); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+
+	if len(request.Streams) != 2 {
+		t.Fatalf("expected '2' streams, but got '%d'", len(request.Streams))
+	}
+
+	stream := request.Streams[0]
+	if stream.Stream["job"] != "o11ybench" || stream.Stream["log_level"] != "info" {
+		t.Fatalf("unexpected stream labels '%v'", stream.Stream)
+	}
+
+	if len(stream.Values) != 2 || stream.Values[0][0] != "1742688000000000000" || stream.Values[0][1] != "level=info" {
+		t.Fatalf("unexpected stream values '%v'", stream.Values)
+	}
+}
+
+func TestLokiEncoderProtobuf(t *testing.T) {
+	encoder := newLokiEncoder(&LokiConfig{
+		Format:         LokiFormatProtobuf,
+		LabelTokens:    []string{"level"},
+		MaxLabelValues: 2,
+	})
+
+	payload, err := encoder.Encode(testLokiOutput("info", "error", "warn", "debug", "info"))
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	data, err := snappy.Decode(nil, payload.Data)
+	if err != nil {
+		t.Fatalf("failed to decode snappy: %v", err)
+	}
+
+	// Decode the `PushRequest` and collect the labels and the entries count of each stream.
+	streams := make(map[string]int)
+	for len(data) > 0 {
+		_, _, n := protowire.ConsumeTag(data)
+		stream, m := protowire.ConsumeBytes(data[n:])
+		data = data[n+m:]
+
+		var labels string
+		for len(stream) > 0 {
+			num, _, n := protowire.ConsumeTag(stream)
+			value, m := protowire.ConsumeBytes(stream[n:])
+			stream = stream[n+m:]
+
+			switch num {
+			case 1:
+				labels = string(value)
+			case 2:
+				streams[labels]++
+			}
+		}
+	}
+
+	// The cardinality of the level label is limited to 2.
+	if len(streams) != 2 {
+		t.Fatalf("expected '2' streams, but got '%v'", streams)
+	}
+
+	if streams[`{log_level="info"}`]+streams[`{log_level="error"}`] != 5 {
+		t.Fatalf("unexpected streams '%v'", streams)
+	}
+}
+
+func TestConfigValidateLokiCompression(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      LokiFormat
+		compression string
+		wantErr     bool
+	}{
+		{name: "protobuf", format: LokiFormatProtobuf},
+		{name: "protobuf with compression", format: LokiFormatProtobuf, compression: CompressionGzip, wantErr: true},
+		{name: "json with compression", format: LokiFormatJSON, compression: CompressionGzip},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loki := LokiConfig{}.defaults()
+			loki.Format = tt.format
+
+			cfg := &Config{
+				Rate:     1,
+				Workers:  1,
+				Arrival:  ArrivalConstant,
+				Protocol: ProtocolLoki,
+				Loki:     loki,
+				Logs:     &LogsGeneratorConfig{RecordsPerRequest: 1},
+				HTTP:     &HTTPConfig{Host: "localhost", Port: 3100, URI: "/loki/api/v1/push", Method: "POST", Compression: tt.compression},
+			}
+
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "level", expected: "level"},
+		{name: "http.status", expected: "http_status"},
+		{name: "1xx", expected: "_1xx"},
+		{name: "2.level", expected: "_2_level"},
+		{name: "_1xx", expected: "_1xx"},
+		{name: "日志", expected: "__"},
+	}
+
+	for _, tt := range tests {
+		if actual := sanitizeLabelName(tt.name); actual != tt.expected {
+			t.Fatalf("sanitizeLabelName('%s'): '%s', expected: '%s'", tt.name, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,269 @@
+package loader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+const (
+	// ContentTypeProtobuf is the content type of the protobuf payload.
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+// otlpEncoder encodes the generated logs into the OTLP `ExportLogsServiceRequest` and the generated metrics into the `ExportMetricsServiceRequest` in protobuf.
+type otlpEncoder struct {
+	cfg *OTLPConfig
+
+	// startTime is the start time of the cumulative counters.
+	startTime time.Time
+}
+
+var (
+	_ Encoder        = &otlpEncoder{}
+	_ messageEncoder = &otlpEncoder{}
+)
+
+func (e *otlpEncoder) Encode(output *generator.GeneratorOutput) (*Payload, error) {
+	request, err := e.message(output)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := proto.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Payload{Data: data, ContentType: ContentTypeProtobuf}, nil
+}
+
+func (e *otlpEncoder) message(output *generator.GeneratorOutput) (proto.Message, error) {
+	if len(output.Metrics) > 0 {
+		return e.metricsRequest(output), nil
+	}
+
+	return e.logsRequest(output)
+}
+
+// metricsRequest converts the generated series into the OTLP `ExportMetricsServiceRequest`.
+// The series of the same metric are grouped into one metric. The counters are the monotonic cumulative sums and the others are the gauges.
+func (e *otlpEncoder) metricsRequest(output *generator.GeneratorOutput) *colmetricspb.ExportMetricsServiceRequest {
+	var (
+		startTime = uint64(e.startTime.UnixNano())
+		byName    = make(map[string]*metricspb.Metric)
+		metricsPB []*metricspb.Metric
+	)
+	for _, series := range output.Metrics {
+		var (
+			name       string
+			attributes = make([]*commonpb.KeyValue, 0, len(series.Labels))
+		)
+		for _, label := range series.Labels {
+			if label.Name == metrics.MetricNameLabel {
+				name = label.Value
+				continue
+			}
+			attributes = append(attributes, &commonpb.KeyValue{
+				Key:   label.Name,
+				Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: label.Value}},
+			})
+		}
+
+		metric, ok := byName[name]
+		if !ok {
+			metric = &metricspb.Metric{Name: name}
+			if series.Type == metrics.MetricTypeCounter {
+				metric.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					IsMonotonic:            true,
+				}}
+			} else {
+				metric.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}
+			}
+			byName[name] = metric
+			metricsPB = append(metricsPB, metric)
+		}
+
+		for _, sample := range series.Samples {
+			point := &metricspb.NumberDataPoint{
+				Attributes:   attributes,
+				TimeUnixNano: uint64(sample.Timestamp) * uint64(time.Millisecond),
+				Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: sample.Value},
+			}
+
+			switch data := metric.Data.(type) {
+			case *metricspb.Metric_Sum:
+				point.StartTimeUnixNano = startTime
+				data.Sum.DataPoints = append(data.Sum.DataPoints, point)
+			case *metricspb.Metric_Gauge:
+				data.Gauge.DataPoints = append(data.Gauge.DataPoints, point)
+			}
+		}
+	}
+
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{
+			{
+				Resource: &resourcepb.Resource{
+					Attributes: resourceAttributes(e.cfg.ResourceAttributes),
+				},
+				ScopeMetrics: []*metricspb.ScopeMetrics{
+					{
+						Scope: &commonpb.InstrumentationScope{
+							Name:    e.cfg.ScopeName,
+							Version: e.cfg.ScopeVersion,
+						},
+						Metrics: metricsPB,
+					},
+				},
+			},
+		},
+	}
+}
+
+// logsRequest converts the generated logs into the OTLP `ExportLogsServiceRequest`.
+func (e *otlpEncoder) logsRequest(output *generator.GeneratorOutput) (*collogspb.ExportLogsServiceRequest, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for the otlp protocol")
+	}
+
+	var (
+		observedTime = uint64(time.Now().UnixNano())
+		records      = make([]*logspb.LogRecord, 0, len(output.Logs))
+	)
+	for _, log := range output.Logs {
+		records = append(records, e.logRecord(log, observedTime))
+	}
+
+	return &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{
+			{
+				Resource: &resourcepb.Resource{
+					Attributes: resourceAttributes(e.cfg.ResourceAttributes),
+				},
+				ScopeLogs: []*logspb.ScopeLogs{
+					{
+						Scope: &commonpb.InstrumentationScope{
+							Name:    e.cfg.ScopeName,
+							Version: e.cfg.ScopeVersion,
+						},
+						LogRecords: records,
+					},
+				},
+			},
+		},
+	}, nil
+}
+
+func (e *otlpEncoder) logRecord(log *logstypes.LogRecord, observedTime uint64) *logspb.LogRecord {
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(log.Timestamp.UnixNano()),
+		ObservedTimeUnixNano: observedTime,
+		Attributes:           make([]*commonpb.KeyValue, 0, len(log.Fields)),
+	}
+
+	for _, field := range log.Fields {
+		switch field.Name {
+		case e.cfg.BodyToken:
+			record.Body = anyValue(field.Value)
+		case e.cfg.SeverityToken:
+			record.SeverityText = fmt.Sprintf("%v", field.Value)
+			record.SeverityNumber = severityNumber(record.SeverityText)
+		default:
+			record.Attributes = append(record.Attributes, &commonpb.KeyValue{
+				Key:   field.Key,
+				Value: anyValue(field.Value),
+			})
+		}
+	}
+
+	// Use the rendered log line as the body if the body token is not set.
+	if record.Body == nil {
+		record.Body = &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: string(log.Line)}}
+	}
+
+	return record
+}
+
+func resourceAttributes(attributes map[string]string) []*commonpb.KeyValue {
+	// Sort the keys to make the output stable.
+	keys := make([]string, 0, len(attributes))
+	for k := range attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]*commonpb.KeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, &commonpb.KeyValue{
+			Key:   k,
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: attributes[k]}},
+		})
+	}
+
+	return kvs
+}
+
+// anyValue converts the generated token value into the OTLP `AnyValue`.
+func anyValue(value any) *commonpb.AnyValue {
+	switch v := value.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case uint64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	default:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("%v", v)}}
+	}
+}
+
+// severityNumber maps the log level into the OTLP severity number. It supports the levels of apache, syslog and the general log levels.
+func severityNumber(level string) logspb.SeverityNumber {
+	level = strings.ToLower(strings.TrimSpace(level))
+
+	switch {
+	case strings.HasPrefix(level, "trace"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_TRACE
+	case strings.HasPrefix(level, "debug"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG
+	case strings.HasPrefix(level, "info"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO
+	case strings.HasPrefix(level, "notice"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_INFO2
+	case strings.HasPrefix(level, "warn"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_WARN
+	case strings.HasPrefix(level, "err"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR
+	case strings.HasPrefix(level, "crit"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR2
+	case strings.HasPrefix(level, "alert"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_ERROR3
+	case strings.HasPrefix(level, "emerg"), strings.HasPrefix(level, "fatal"), strings.HasPrefix(level, "panic"):
+		return logspb.SeverityNumber_SEVERITY_NUMBER_FATAL
+	}
+
+	return logspb.SeverityNumber_SEVERITY_NUMBER_UNSPECIFIED
+}
@@ -0,0 +1,212 @@
+package loader
+
+import (
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+func TestOTLPEncoder(t *testing.T) {
+	timestamp, _ := time.Parse(time.RFC3339, "2025-03-23T00:00:00Z")
+	output := &generator.GeneratorOutput{
+		Logs: []*logstypes.LogRecord{
+			{
+				Timestamp: timestamp,
+				Fields: []*logstypes.LogField{
+					{Name: "level", Key: "level", Value: "ERROR"},
+					{Name: "message", Key: "msg", Value: "something is wrong"},
+					{Name: "username", Key: "user", Value: "o11ybench"},
+				},
+				Line: []byte("line 1"),
+			},
+			{
+				Timestamp: timestamp,
+				Fields: []*logstypes.LogField{
+					{Name: "level", Key: "level", Value: "warning"},
+					{Name: "message", Key: "msg", Value: "something may be wrong"},
+					{Name: "username", Key: "user", Value: "o11ybench"},
+				},
+				Line: []byte("line 2"),
+			},
+		},
+	}
+
+	encoder, err := NewEncoder(&Config{
+		Protocol: ProtocolOTLP,
+		OTLP: &OTLPConfig{
+			ResourceAttributes: map[string]string{"service.name": "test"},
+			ScopeName:          "o11ybench",
+			BodyToken:          "message",
+			SeverityToken:      "level",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	payload, err := encoder.Encode(output)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	if payload.ContentType != ContentTypeProtobuf {
+		t.Fatalf("expected content type '%s', but got '%s'", ContentTypeProtobuf, payload.ContentType)
+	}
+
+	var request collogspb.ExportLogsServiceRequest
+	if err := proto.Unmarshal(payload.Data, &request); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+
+	resourceLogs := request.GetResourceLogs()[0]
+	if got := resourceLogs.GetResource().GetAttributes()[0].GetValue().GetStringValue(); got != "test" {
+		t.Fatalf("expected resource attribute 'test', but got '%s'", got)
+	}
+
+	scopeLogs := resourceLogs.GetScopeLogs()[0]
+	if scopeLogs.GetScope().GetName() != "o11ybench" {
+		t.Fatalf("expected scope name 'o11ybench', but got '%s'", scopeLogs.GetScope().GetName())
+	}
+
+	records := scopeLogs.GetLogRecords()
+	if len(records) != len(output.Logs) {
+		t.Fatalf("expected '%d' records, but got '%d'", len(output.Logs), len(records))
+	}
+
+	tests := []struct {
+		severityText   string
+		severityNumber logspb.SeverityNumber
+		body           string
+	}{
+		{
+			severityText:   "ERROR",
+			severityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_ERROR,
+			body:           "something is wrong",
+		},
+		{
+			severityText:   "warning",
+			severityNumber: logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+			body:           "something may be wrong",
+		},
+	}
+
+	for i, tt := range tests {
+		record := records[i]
+		if record.GetTimeUnixNano() != uint64(timestamp.UnixNano()) {
+			t.Errorf("Run test [%d]: expected timestamp '%d', but got '%d'", i, timestamp.UnixNano(), record.GetTimeUnixNano())
+		}
+
+		if record.GetSeverityText() != tt.severityText || record.GetSeverityNumber() != tt.severityNumber {
+			t.Errorf("Run test [%d]: expected severity '%s(%s)', but got '%s(%s)'", i, tt.severityText, tt.severityNumber, record.GetSeverityText(), record.GetSeverityNumber())
+		}
+
+		if record.GetBody().GetStringValue() != tt.body {
+			t.Errorf("Run test [%d]: expected body '%s', but got '%s'", i, tt.body, record.GetBody().GetStringValue())
+		}
+
+		attributes := record.GetAttributes()
+		if len(attributes) != 1 || attributes[0].GetKey() != "user" || attributes[0].GetValue().GetStringValue() != "o11ybench" {
+			t.Errorf("Run test [%d]: unexpected attributes '%v'", i, attributes)
+		}
+	}
+}
+
+func TestOTLPEncoderMetrics(t *testing.T) {
+	output := &generator.GeneratorOutput{
+		Metrics: []*metrics.Series{
+			{
+				Labels: []*metrics.LabelPair{
+					{Name: metrics.MetricNameLabel, Value: "cpu_usage"},
+					{Name: "host", Value: "a"},
+				},
+				Samples: []*metrics.Sample{
+					{Timestamp: 1742688000000, Value: 1.5},
+					{Timestamp: 1742688015000, Value: 2.5},
+				},
+			},
+			{
+				Labels: []*metrics.LabelPair{
+					{Name: metrics.MetricNameLabel, Value: "cpu_usage"},
+					{Name: "host", Value: "b"},
+				},
+				Samples: []*metrics.Sample{
+					{Timestamp: 1742688000000, Value: 3.5},
+				},
+			},
+			{
+				Labels: []*metrics.LabelPair{
+					{Name: metrics.MetricNameLabel, Value: "http_requests_total"},
+					{Name: "host", Value: "a"},
+				},
+				Type: metrics.MetricTypeCounter,
+				Samples: []*metrics.Sample{
+					{Timestamp: 1742688000000, Value: 10},
+				},
+			},
+		},
+	}
+
+	encoder, err := NewEncoder(&Config{
+		Protocol: ProtocolOTLP,
+		OTLP: &OTLPConfig{
+			ResourceAttributes: map[string]string{"service.name": "test"},
+			ScopeName:          "o11ybench",
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to create encoder: %v", err)
+	}
+
+	payload, err := encoder.Encode(output)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	var request colmetricspb.ExportMetricsServiceRequest
+	if err := proto.Unmarshal(payload.Data, &request); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+
+	resourceMetrics := request.GetResourceMetrics()[0]
+	if got := resourceMetrics.GetResource().GetAttributes()[0].GetValue().GetStringValue(); got != "test" {
+		t.Fatalf("expected resource attribute 'test', but got '%s'", got)
+	}
+
+	metricsPB := resourceMetrics.GetScopeMetrics()[0].GetMetrics()
+	if len(metricsPB) != 2 {
+		t.Fatalf("expected '2' metrics, but got '%d'", len(metricsPB))
+	}
+
+	gauge := metricsPB[0]
+	if gauge.GetName() != "cpu_usage" || len(gauge.GetGauge().GetDataPoints()) != 3 {
+		t.Fatalf("unexpected gauge '%v'", gauge)
+	}
+
+	point := gauge.GetGauge().GetDataPoints()[1]
+	if point.GetTimeUnixNano() != 1742688015000*uint64(time.Millisecond) || point.GetAsDouble() != 2.5 {
+		t.Fatalf("unexpected data point '%v'", point)
+	}
+
+	attributes := point.GetAttributes()
+	if len(attributes) != 1 || attributes[0].GetKey() != "host" || attributes[0].GetValue().GetStringValue() != "a" {
+		t.Fatalf("unexpected attributes '%v'", attributes)
+	}
+
+	sum := metricsPB[1].GetSum()
+	if metricsPB[1].GetName() != "http_requests_total" || !sum.GetIsMonotonic() || sum.GetAggregationTemporality() != metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Fatalf("unexpected sum '%v'", metricsPB[1])
+	}
+
+	if point := sum.GetDataPoints()[0]; point.GetStartTimeUnixNano() == 0 || point.GetAsDouble() != 10 {
+		t.Fatalf("unexpected data point '%v'", point)
+	}
+}
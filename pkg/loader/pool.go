@@ -0,0 +1,176 @@
+package loader
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+// payloadPool is the pool of the payloads generated before the run. The requests take the payloads in the round-robin order.
+type payloadPool struct {
+	cfg *PoolConfig
+
+	// outputs are the generated data. They are used unless the payloads are prepared by the sender.
+	outputs []*pooledOutput
+
+	// prepared are the payloads encoded and compressed by the sender unless the timestamps are shifted.
+	prepared []*pooledPayload
+
+	// file is the temporary file of the prepared payloads for the `file` storage.
+	file *os.File
+
+	next atomic.Uint64
+}
+
+// pooledOutput is the generated data in the pool with the time when it's generated.
+type pooledOutput struct {
+	output    *generator.GeneratorOutput
+	generated time.Time
+}
+
+// pooledPayload is the prepared payload in the pool. The data of the payload is nil if it's stored in the file.
+type pooledPayload struct {
+	payload *preparedPayload
+
+	// offset and size are the position of the data in the file.
+	offset int64
+	size   int
+}
+
+// newPayloadPool generates the payloads of the pool. The payloads are also prepared by the sender if the timestamps are kept and the sender supports it.
+// The shifted timestamps are encoded for each request, so the payloads can't be prepared ahead.
+func newPayloadPool(cfg *PoolConfig, gen generator.Generator, options func() *generator.GeneratorOptions, s sender) (*payloadPool, error) {
+	p := &payloadPool{cfg: cfg}
+
+	ps, prepare := s.(preparedSender)
+	prepare = prepare && cfg.Timestamps != PoolTimestampsShift
+
+	var w *bufio.Writer
+	if cfg.Storage == PoolStorageFile {
+		if !prepare {
+			return nil, fmt.Errorf("the file storage of the pool is not supported by the target")
+		}
+
+		file, err := os.CreateTemp(cfg.Dir, "o11ybench-pool-*")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create the file of the pool: %w", err)
+		}
+		p.file = file
+		w = bufio.NewWriter(file)
+	}
+
+	var offset int64
+	for i := 0; i < cfg.Size; i++ {
+		generated := time.Now()
+		output, err := gen.Generate(options())
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("failed to generate the payload of the pool: %w", err)
+		}
+
+		if !prepare {
+			p.outputs = append(p.outputs, &pooledOutput{output: output, generated: generated})
+			continue
+		}
+
+		payload, err := ps.prepare(output)
+		if err != nil {
+			p.close()
+			return nil, fmt.Errorf("failed to prepare the payload of the pool: %w", err)
+		}
+
+		// The compression is done before the run, so it's not counted as the cost of the requests.
+		payload.compressTime = 0
+
+		pooled := &pooledPayload{payload: payload}
+		if w != nil {
+			if _, err := w.Write(payload.data); err != nil {
+				p.close()
+				return nil, fmt.Errorf("failed to write the payload of the pool: %w", err)
+			}
+			pooled.offset, pooled.size = offset, len(payload.data)
+			offset += int64(len(payload.data))
+			payload.data = nil
+		}
+		p.prepared = append(p.prepared, pooled)
+	}
+
+	if w != nil {
+		if err := w.Flush(); err != nil {
+			p.close()
+			return nil, fmt.Errorf("failed to write the payload of the pool: %w", err)
+		}
+	}
+
+	return p, nil
+}
+
+// get returns the next payload of the pool. It's either the generated data or the prepared payload.
+func (p *payloadPool) get() (*generator.GeneratorOutput, *preparedPayload, error) {
+	i := p.next.Add(1) - 1
+
+	if len(p.prepared) == 0 {
+		pooled := p.outputs[i%uint64(len(p.outputs))]
+		if p.cfg.Timestamps != PoolTimestampsShift {
+			return pooled.output, nil, nil
+		}
+		return shiftTimestamps(pooled.output, time.Since(pooled.generated)), nil, nil
+	}
+
+	pooled := p.prepared[i%uint64(len(p.prepared))]
+	if p.file == nil {
+		return nil, pooled.payload, nil
+	}
+
+	// The data is read by the position, so the workers can read the file concurrently.
+	payload := *pooled.payload
+	payload.data = make([]byte, pooled.size)
+	if _, err := p.file.ReadAt(payload.data, pooled.offset); err != nil {
+		return nil, nil, fmt.Errorf("failed to read the payload of the pool: %w", err)
+	}
+
+	return nil, &payload, nil
+}
+
+// close removes the temporary file of the pool.
+func (p *payloadPool) close() {
+	if p.file != nil {
+		p.file.Close()
+		os.Remove(p.file.Name())
+	}
+}
+
+// shiftTimestamps returns a copy of the generated data with the timestamps of the log records and the samples shifted by d.
+// The rendered data and lines are not changed, which is why the shifted timestamps are only allowed for the protocols encoding the timestamps of the records.
+// They and the other fields are shared with the pooled data, so they must not be modified.
+func shiftTimestamps(output *generator.GeneratorOutput, d time.Duration) *generator.GeneratorOutput {
+	shifted := &generator.GeneratorOutput{Data: output.Data}
+
+	if len(output.Logs) > 0 {
+		shifted.Logs = make([]*logstypes.LogRecord, len(output.Logs))
+		for i, log := range output.Logs {
+			record := *log
+			record.Timestamp = log.Timestamp.Add(d)
+			shifted.Logs[i] = &record
+		}
+	}
+
+	if len(output.Metrics) > 0 {
+		shifted.Metrics = make([]*metrics.Series, len(output.Metrics))
+		for i, series := range output.Metrics {
+			samples := make([]*metrics.Sample, len(series.Samples))
+			for j, sample := range series.Samples {
+				samples[j] = &metrics.Sample{Timestamp: sample.Timestamp + d.Milliseconds(), Value: sample.Value}
+			}
+			shifted.Metrics[i] = &metrics.Series{Labels: series.Labels, Type: series.Type, Samples: samples}
+		}
+	}
+
+	return shifted
+}
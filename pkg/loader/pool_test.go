@@ -0,0 +1,231 @@
+package loader
+
+import (
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+// countingGenerator counts the calls of Generate. Each call generates a log record with the current time.
+type countingGenerator struct {
+	calls atomic.Int64
+}
+
+func (g *countingGenerator) Generate(options *generator.GeneratorOptions) (*generator.GeneratorOutput, error) {
+	g.calls.Add(1)
+	return &generator.GeneratorOutput{
+		Data: []byte("test"),
+		Logs: []*logstypes.LogRecord{{Timestamp: time.Now(), Line: []byte("test")}},
+	}, nil
+}
+
+func TestLoaderPool(t *testing.T) {
+	tests := []struct {
+		name     string
+		protocol Protocol
+		pool     *PoolConfig
+
+		// wantTimestamps is the number of the distinct timestamps received by the target. It's not checked for the raw protocol.
+		wantTimestamps int
+	}{
+		{name: "keep timestamps", protocol: ProtocolOTLP, pool: &PoolConfig{Size: 3, Timestamps: PoolTimestampsKeep, Storage: PoolStorageMemory}, wantTimestamps: 3},
+		{name: "shift timestamps", protocol: ProtocolOTLP, pool: &PoolConfig{Size: 3, Timestamps: PoolTimestampsShift, Storage: PoolStorageMemory}, wantTimestamps: 10},
+		{name: "file storage", protocol: ProtocolRaw, pool: &PoolConfig{Size: 3, Timestamps: PoolTimestampsKeep, Storage: PoolStorageFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				received   atomic.Int64
+				mu         sync.Mutex
+				timestamps = make(map[uint64]bool)
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gr, err := gzip.NewReader(r.Body)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				body, err := io.ReadAll(gr)
+				if err != nil {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+
+				if tt.protocol == ProtocolRaw {
+					if string(body) != "test" {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+				} else {
+					req := &collogspb.ExportLogsServiceRequest{}
+					if err := proto.Unmarshal(body, req); err != nil || len(req.ResourceLogs) != 1 {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					mu.Lock()
+					timestamps[req.ResourceLogs[0].ScopeLogs[0].LogRecords[0].TimeUnixNano] = true
+					mu.Unlock()
+				}
+				received.Add(1)
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			tt.pool.Dir = dir
+
+			cfg := &Config{
+				Rate:     20,
+				Workers:  2,
+				Duration: 500 * time.Millisecond,
+				Protocol: tt.protocol,
+				OTLP:     OTLPConfig{}.defaults(),
+				Pool:     tt.pool,
+				Logs: &LogsGeneratorConfig{
+					RecordsPerRequest: 1,
+				},
+				HTTP: &HTTPConfig{
+					Host:        "127.0.0.1",
+					Port:        server.Listener.Addr().(*net.TCPAddr).Port,
+					URI:         "/api/load",
+					Method:      "POST",
+					Compression: CompressionGzip,
+				},
+			}
+
+			gen := &countingGenerator{}
+			collector := collector.New()
+			loader, err := New(cfg, gen, collector)
+			if err != nil {
+				t.Fatalf("failed to create loader: %v", err)
+			}
+
+			if err := loader.Start(); err != nil {
+				t.Fatalf("failed to start loader: %v", err)
+			}
+
+			// The payloads are only generated for the pool, and the requests cycle through them.
+			if gen.calls.Load() != int64(tt.pool.Size) {
+				t.Fatalf("unexpected calls of the generator: '%d', expected: '%d'", gen.calls.Load(), tt.pool.Size)
+			}
+
+			if collector.SuccessCount() != 10 || received.Load() != 10 {
+				t.Fatalf("unexpected success: '%d' and received requests: '%d', expected: '10'", collector.SuccessCount(), received.Load())
+			}
+
+			if collector.PoolSize() != tt.pool.Size || collector.SendTime() <= 0 {
+				t.Fatalf("unexpected pool size: '%d' and send time: '%s'", collector.PoolSize(), collector.SendTime())
+			}
+
+			// The kept timestamps are sent again in each cycle of the pool, and the shifted timestamps are new for each request.
+			if tt.protocol != ProtocolRaw && len(timestamps) != tt.wantTimestamps {
+				t.Fatalf("unexpected distinct timestamps: '%d', expected: '%d'", len(timestamps), tt.wantTimestamps)
+			}
+
+			// The compression is done before the run if the timestamps are kept.
+			if (collector.CompressionTime() > 0) != (tt.pool.Timestamps == PoolTimestampsShift) {
+				t.Fatalf("unexpected compression time: '%s'", collector.CompressionTime())
+			}
+
+			// The temporary file of the pool is removed after the run.
+			if entries, err := os.ReadDir(dir); err != nil || len(entries) != 0 {
+				t.Fatalf("the temporary file of the pool is not removed: '%v', '%v'", entries, err)
+			}
+		})
+	}
+}
+
+func TestShiftTimestamps(t *testing.T) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	output := &generator.GeneratorOutput{
+		Data: []byte("line"),
+		Logs: []*logstypes.LogRecord{{Timestamp: ts, Line: []byte("line")}},
+		Metrics: []*metrics.Series{
+			{Labels: []*metrics.LabelPair{{Name: "__name__", Value: "up"}}, Samples: []*metrics.Sample{{Timestamp: ts.UnixMilli(), Value: 1}}},
+		},
+	}
+
+	shifted := shiftTimestamps(output, time.Minute)
+	if !shifted.Logs[0].Timestamp.Equal(ts.Add(time.Minute)) || string(shifted.Logs[0].Line) != "line" {
+		t.Fatalf("unexpected shifted log: '%s' '%s'", shifted.Logs[0].Timestamp, shifted.Logs[0].Line)
+	}
+
+	if shifted.Metrics[0].Samples[0].Timestamp != ts.Add(time.Minute).UnixMilli() || shifted.Metrics[0].Samples[0].Value != 1 {
+		t.Fatalf("unexpected shifted sample: '%v'", shifted.Metrics[0].Samples[0])
+	}
+
+	// The pooled data is not modified.
+	if !output.Logs[0].Timestamp.Equal(ts) || output.Metrics[0].Samples[0].Timestamp != ts.UnixMilli() {
+		t.Fatalf("the pooled data is modified")
+	}
+}
+
+func TestConfigValidatePool(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr bool
+	}{
+		{name: "keep timestamps with the raw protocol", cfg: &Config{Protocol: ProtocolRaw, HTTP: &HTTPConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsKeep}}},
+		{name: "shift timestamps with the raw protocol", cfg: &Config{Protocol: ProtocolRaw, HTTP: &HTTPConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}, wantErr: true},
+		{name: "shift timestamps with the elasticsearch protocol", cfg: &Config{Protocol: ProtocolElasticsearch, HTTP: &HTTPConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}, wantErr: true},
+		{name: "shift timestamps with the syslog target", cfg: &Config{Protocol: ProtocolRaw, Syslog: &SyslogConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}, wantErr: true},
+		{name: "shift timestamps with the otlp protocol", cfg: &Config{Protocol: ProtocolOTLP, GRPC: &GRPCConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}},
+		{name: "shift timestamps with the forward target", cfg: &Config{Protocol: ProtocolRaw, Forward: &ForwardConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}},
+		{name: "shift timestamps of the metrics", cfg: &Config{Protocol: ProtocolRemoteWrite, Metrics: &MetricsGeneratorConfig{}, HTTP: &HTTPConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsShift}}},
+		{name: "keep timestamps of the metrics", cfg: &Config{Protocol: ProtocolRemoteWrite, Metrics: &MetricsGeneratorConfig{}, HTTP: &HTTPConfig{}, Pool: &PoolConfig{Timestamps: PoolTimestampsKeep}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			cfg.Rate, cfg.Workers, cfg.Arrival = 1, 1, ArrivalConstant
+			cfg.Pool.Size = 10
+
+			// Only the errors of the pool are checked, since the other parts of the config are incomplete.
+			err := cfg.Validate()
+			if wantPoolErr := err != nil && strings.Contains(err.Error(), "pool"); wantPoolErr != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoolConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		pool    *PoolConfig
+		wantErr bool
+	}{
+		{name: "memory", pool: &PoolConfig{Size: 10}},
+		{name: "file", pool: &PoolConfig{Size: 10, Timestamps: PoolTimestampsKeep, Storage: PoolStorageFile}},
+		{name: "file with the shifted timestamps", pool: &PoolConfig{Size: 10, Timestamps: PoolTimestampsShift, Storage: PoolStorageFile}, wantErr: true},
+		{name: "invalid size", pool: &PoolConfig{}, wantErr: true},
+		{name: "invalid timestamps", pool: &PoolConfig{Size: 10, Timestamps: "now"}, wantErr: true},
+		{name: "invalid storage", pool: &PoolConfig{Size: 10, Storage: "disk"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.pool.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// duration returns the total duration of the stages.
+func (c *ProfileConfig) duration() time.Duration {
+	var total time.Duration
+	for _, stage := range c.Stages {
+		total += stage.Duration
+	}
+
+	return total
+}
+
+// stageAt returns the index of the stage at the given elapsed time from the start, and the elapsed time from the start of the stage.
+// It returns -1 if the elapsed time is beyond the profile.
+func (c *ProfileConfig) stageAt(elapsed time.Duration) (int, time.Duration) {
+	for i, stage := range c.Stages {
+		if elapsed < stage.Duration {
+			return i, elapsed
+		}
+		elapsed -= stage.Duration
+	}
+
+	return -1, 0
+}
+
+// rateAt returns the rate at the given elapsed time from the start. It returns 0 if the elapsed time is beyond the profile.
+func (c *ProfileConfig) rateAt(elapsed time.Duration) float64 {
+	i, offset := c.stageAt(elapsed)
+	if i < 0 {
+		return 0
+	}
+
+	return c.Stages[i].rateAt(offset)
+}
+
+// name returns the name of the stage with the given index.
+func (c *StageConfig) name(index int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	return fmt.Sprintf("stage-%d", index)
+}
+
+// rateAt returns the rate at the given elapsed time from the start of the stage.
+func (c *StageConfig) rateAt(elapsed time.Duration) float64 {
+	progress := float64(elapsed) / float64(c.Duration)
+
+	switch c.Type {
+	case StageTypeRamp:
+		return c.From + (c.To-c.From)*progress
+	case StageTypeStep:
+		level := math.Min(math.Floor(progress*float64(c.Steps)), float64(c.Steps-1))
+		return c.From + (c.To-c.From)*level/float64(c.Steps-1)
+	case StageTypeSpike:
+		spikeStart := (c.Duration - c.SpikeDuration) / 2
+		if elapsed >= spikeStart && elapsed < spikeStart+c.SpikeDuration {
+			return c.PeakRate
+		}
+		return c.Rate
+	case StageTypeSine:
+		return c.Rate + c.Amplitude*math.Sin(2*math.Pi*float64(elapsed)/float64(c.Period))
+	}
+
+	return c.Rate
+}
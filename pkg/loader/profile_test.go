@@ -0,0 +1,81 @@
+package loader
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestStageRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		stage    *StageConfig
+		elapsed  time.Duration
+		expected float64
+	}{
+		{name: "constant", stage: &StageConfig{Type: StageTypeConstant, Duration: 10 * time.Second, Rate: 5}, elapsed: 3 * time.Second, expected: 5},
+		{name: "ramp at start", stage: &StageConfig{Type: StageTypeRamp, Duration: 10 * time.Second, From: 10, To: 110}, elapsed: 0, expected: 10},
+		{name: "ramp in middle", stage: &StageConfig{Type: StageTypeRamp, Duration: 10 * time.Second, From: 10, To: 110}, elapsed: 5 * time.Second, expected: 60},
+		{name: "ramp down", stage: &StageConfig{Type: StageTypeRamp, Duration: 10 * time.Second, From: 100, To: 0}, elapsed: 7500 * time.Millisecond, expected: 25},
+		{name: "first step", stage: &StageConfig{Type: StageTypeStep, Duration: 4 * time.Second, From: 10, To: 40, Steps: 4}, elapsed: 999 * time.Millisecond, expected: 10},
+		{name: "second step", stage: &StageConfig{Type: StageTypeStep, Duration: 4 * time.Second, From: 10, To: 40, Steps: 4}, elapsed: time.Second, expected: 20},
+		{name: "last step", stage: &StageConfig{Type: StageTypeStep, Duration: 4 * time.Second, From: 10, To: 40, Steps: 4}, elapsed: 3999 * time.Millisecond, expected: 40},
+		{name: "before spike", stage: &StageConfig{Type: StageTypeSpike, Duration: 10 * time.Second, Rate: 10, PeakRate: 100, SpikeDuration: 2 * time.Second}, elapsed: 3999 * time.Millisecond, expected: 10},
+		{name: "in spike", stage: &StageConfig{Type: StageTypeSpike, Duration: 10 * time.Second, Rate: 10, PeakRate: 100, SpikeDuration: 2 * time.Second}, elapsed: 4 * time.Second, expected: 100},
+		{name: "after spike", stage: &StageConfig{Type: StageTypeSpike, Duration: 10 * time.Second, Rate: 10, PeakRate: 100, SpikeDuration: 2 * time.Second}, elapsed: 6 * time.Second, expected: 10},
+		{name: "sine peak", stage: &StageConfig{Type: StageTypeSine, Duration: time.Minute, Rate: 50, Amplitude: 20, Period: 40 * time.Second}, elapsed: 10 * time.Second, expected: 70},
+		{name: "sine trough", stage: &StageConfig{Type: StageTypeSine, Duration: time.Minute, Rate: 50, Amplitude: 20, Period: 40 * time.Second}, elapsed: 30 * time.Second, expected: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.stage.validate(); err != nil {
+				t.Fatalf("invalid stage: %v", err)
+			}
+
+			if actual := tt.stage.rateAt(tt.elapsed); math.Abs(actual-tt.expected) > 1e-9 {
+				t.Fatalf("actual rate: '%f', expected rate: '%f'", actual, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSchedulerProfile(t *testing.T) {
+	profile := &ProfileConfig{
+		Stages: []*StageConfig{
+			{Type: StageTypeRamp, Duration: 10 * time.Second, From: 0, To: 100},
+			{Type: StageTypeConstant, Duration: 5 * time.Second, Rate: 20},
+			{Type: StageTypeSpike, Duration: 10 * time.Second, Rate: 10, PeakRate: 110, SpikeDuration: time.Second},
+		},
+	}
+
+	// The expected requests of each stage are the integral of the rate over the stage.
+	expected := []float64{500, 100, 200}
+
+	for _, arrival := range []Arrival{ArrivalConstant, ArrivalPoisson} {
+		start := time.Now()
+		s := newScheduler(start, 0, profile, arrival)
+
+		counts := make([]float64, len(profile.Stages))
+		for {
+			intended := s.next()
+			i, _ := profile.stageAt(intended.Sub(start))
+			if i < 0 {
+				break
+			}
+			counts[i]++
+		}
+
+		// The Poisson arrival is random, so the counts are allowed to deviate by about 3 standard deviations.
+		for i := range counts {
+			delta := 1.0
+			if arrival == ArrivalPoisson {
+				delta = 3 * math.Sqrt(expected[i])
+			}
+
+			if math.Abs(counts[i]-expected[i]) > delta {
+				t.Fatalf("arrival '%s': actual requests of stage %d: '%f', expected: '%f', delta: '%f'", arrival, i, counts[i], expected[i], delta)
+			}
+		}
+	}
+}
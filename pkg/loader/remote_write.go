@@ -0,0 +1,79 @@
+package loader
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// remoteWriteEncoder encodes the generated metrics into the Prometheus remote-write v1 `WriteRequest` in snappy-compressed protobuf.
+type remoteWriteEncoder struct{}
+
+var (
+	_ Encoder         = &remoteWriteEncoder{}
+	_ responseChecker = &remoteWriteEncoder{}
+)
+
+func (e *remoteWriteEncoder) Encode(output *generator.GeneratorOutput) (*Payload, error) {
+	if len(output.Metrics) == 0 {
+		return nil, fmt.Errorf("no metrics are generated for the remoteWrite protocol")
+	}
+
+	// The protobuf messages of the remote-write v1:
+	//
+	//	message WriteRequest { repeated TimeSeries timeseries = 1; }
+	//	message TimeSeries { repeated Label labels = 1; repeated Sample samples = 2; }
+	//	message Label { string name = 1; string value = 2; }
+	//	message Sample { double value = 1; int64 timestamp = 2; }
+	var request []byte
+	for _, series := range output.Metrics {
+		var ts []byte
+		for _, label := range series.Labels {
+			var l []byte
+			l = protowire.AppendTag(l, 1, protowire.BytesType)
+			l = protowire.AppendString(l, label.Name)
+			l = protowire.AppendTag(l, 2, protowire.BytesType)
+			l = protowire.AppendString(l, label.Value)
+
+			ts = protowire.AppendTag(ts, 1, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, l)
+		}
+
+		for _, sample := range series.Samples {
+			var s []byte
+			s = protowire.AppendTag(s, 1, protowire.Fixed64Type)
+			s = protowire.AppendFixed64(s, math.Float64bits(sample.Value))
+			s = protowire.AppendTag(s, 2, protowire.VarintType)
+			s = protowire.AppendVarint(s, uint64(sample.Timestamp))
+
+			ts = protowire.AppendTag(ts, 2, protowire.BytesType)
+			ts = protowire.AppendBytes(ts, s)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, ts)
+	}
+
+	return &Payload{
+		Data:        snappy.Encode(nil, request),
+		ContentType: ContentTypeProtobuf,
+		RawSize:     len(request),
+		Headers: map[string]string{
+			"Content-Encoding":                  "snappy",
+			"X-Prometheus-Remote-Write-Version": "0.1.0",
+		},
+	}, nil
+}
+
+// checkResponse accepts all the 2xx status codes since the most of the remote-write receivers respond `204 No Content`.
+func (e *remoteWriteEncoder) checkResponse(statusCode int, body []byte) error {
+	if statusCode < 200 || statusCode >= 300 {
+		return &statusCodeError{code: statusCode, body: body}
+	}
+
+	return nil
+}
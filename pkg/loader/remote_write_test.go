@@ -0,0 +1,115 @@
+package loader
+
+import (
+	"math"
+	"testing"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	"github.com/zyy17/o11ybench/pkg/generator/metrics"
+)
+
+func TestRemoteWriteEncoder(t *testing.T) {
+	output := &generator.GeneratorOutput{
+		Metrics: []*metrics.Series{
+			{
+				Labels: []*metrics.LabelPair{
+					{Name: metrics.MetricNameLabel, Value: "cpu_usage"},
+					{Name: "host", Value: "a"},
+				},
+				Samples: []*metrics.Sample{
+					{Timestamp: 1742688000000, Value: 1.5},
+					{Timestamp: 1742688015000, Value: 2.5},
+				},
+			},
+		},
+	}
+
+	payload, err := (&remoteWriteEncoder{}).Encode(output)
+	if err != nil {
+		t.Fatalf("failed to encode: %v", err)
+	}
+
+	if payload.Headers["Content-Encoding"] != "snappy" {
+		t.Fatalf("expected 'Content-Encoding: snappy', but got '%s'", payload.Headers["Content-Encoding"])
+	}
+
+	data, err := snappy.Decode(nil, payload.Data)
+	if err != nil {
+		t.Fatalf("failed to decode snappy: %v", err)
+	}
+
+	// Decode the `WriteRequest` that only has one time series.
+	_, _, n := protowire.ConsumeTag(data)
+	series, _ := protowire.ConsumeBytes(data[n:])
+
+	var (
+		labels  []string
+		samples []float64
+	)
+	for len(series) > 0 {
+		num, _, n := protowire.ConsumeTag(series)
+		field, m := protowire.ConsumeBytes(series[n:])
+		series = series[n+m:]
+
+		switch num {
+		case 1:
+			for len(field) > 0 {
+				_, _, n := protowire.ConsumeTag(field)
+				value, m := protowire.ConsumeBytes(field[n:])
+				field = field[n+m:]
+				labels = append(labels, string(value))
+			}
+		case 2:
+			_, _, n := protowire.ConsumeTag(field)
+			value, _ := protowire.ConsumeFixed64(field[n:])
+			samples = append(samples, math.Float64frombits(value))
+		}
+	}
+
+	if len(labels) != 4 || labels[0] != metrics.MetricNameLabel || labels[1] != "cpu_usage" || labels[2] != "host" || labels[3] != "a" {
+		t.Fatalf("unexpected labels '%v'", labels)
+	}
+
+	if len(samples) != 2 || samples[0] != 1.5 || samples[1] != 2.5 {
+		t.Fatalf("unexpected samples '%v'", samples)
+	}
+}
+
+func TestConfigValidateMetrics(t *testing.T) {
+	httpConfig := &HTTPConfig{Host: "localhost", Port: 9090, URI: "/api/v1/write", Method: "POST"}
+
+	tests := []struct {
+		name     string
+		protocol Protocol
+		http     *HTTPConfig
+		grpc     *GRPCConfig
+		wantErr  bool
+	}{
+		{name: "remoteWrite over http", protocol: ProtocolRemoteWrite, http: httpConfig},
+		{name: "raw over http", protocol: ProtocolRaw, http: httpConfig},
+		{name: "otlp over http", protocol: ProtocolOTLP, http: httpConfig},
+		{name: "otlp over grpc", protocol: ProtocolOTLP, grpc: &GRPCConfig{Host: "localhost", Port: 4317, Connections: 1}},
+		{name: "loki over http", protocol: ProtocolLoki, http: httpConfig, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{
+				Rate:     1,
+				Workers:  1,
+				Arrival:  ArrivalConstant,
+				Protocol: tt.protocol,
+				HTTP:     tt.http,
+				GRPC:     tt.grpc,
+				Metrics:  &MetricsGeneratorConfig{SeriesPerRequest: 1, SamplesPerSeries: 1},
+			}
+
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
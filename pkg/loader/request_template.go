@@ -0,0 +1,131 @@
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator/common"
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+)
+
+// requestTemplates renders the URI and the headers of each request by the values of the request-level tokens.
+type requestTemplates struct {
+	tokens []*RequestTokenConfig
+
+	// uri is the template of the URI. It's nil if the URI is a literal.
+	uri *template.Template
+
+	// headers are the templates of the header values. The literal values are not included.
+	headers map[string]*template.Template
+}
+
+// newRequestTemplates parses the templates of the URI and the headers. It returns nil if none of them is a template.
+// The templates are rendered once by the generated token values, so the unknown tokens and fake kinds are reported before the run.
+func newRequestTemplates(cfg *HTTPConfig) (*requestTemplates, error) {
+	names := make(map[string]bool, len(cfg.Tokens))
+	for _, token := range cfg.Tokens {
+		if token.Name == "" {
+			return nil, fmt.Errorf("name of the request token is required")
+		}
+		if names[token.Name] {
+			return nil, fmt.Errorf("duplicate request token: '%s'", token.Name)
+		}
+		names[token.Name] = true
+
+		if token.Value == nil && token.FakeConfig == nil {
+			return nil, fmt.Errorf("one of value and fake is required for the request token '%s'", token.Name)
+		}
+	}
+
+	t := &requestTemplates{tokens: cfg.Tokens, headers: make(map[string]*template.Template)}
+
+	var err error
+	if t.uri, err = parseRequestTemplate("uri", cfg.URI); err != nil {
+		return nil, err
+	}
+
+	for k, v := range cfg.Headers {
+		tmpl, err := parseRequestTemplate(k, v)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl != nil {
+			t.headers[k] = tmpl
+		}
+	}
+
+	if t.uri == nil && len(t.headers) == 0 {
+		return nil, nil
+	}
+
+	data, err := t.generate()
+	if err != nil {
+		return nil, err
+	}
+
+	if t.uri != nil {
+		if _, err := render(t.uri, data); err != nil {
+			return nil, err
+		}
+	}
+	for _, tmpl := range t.headers {
+		if _, err := render(tmpl, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// parseRequestTemplate parses the template of the URI or the header value. It returns nil if the value is a literal.
+func parseRequestTemplate(name, value string) (*template.Template, error) {
+	if !strings.Contains(value, "{{") {
+		return nil, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of '%s': %w", name, err)
+	}
+
+	return tmpl, nil
+}
+
+// generate generates the values of the tokens for a request. The current time is also available as `Timestamp`.
+func (t *requestTemplates) generate() (map[string]any, error) {
+	data := make(map[string]any, len(t.tokens)+1)
+	data["Timestamp"] = time.Now().UTC()
+
+	for _, token := range t.tokens {
+		if token.Value != nil {
+			data[token.Name] = token.Value
+			continue
+		}
+
+		typ := token.Type
+		if typ == "" {
+			typ = common.ElementTypeString
+		}
+
+		value, err := faker.Fake(typ, token.FakeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate the request token '%s': %w", token.Name, err)
+		}
+		data[token.Name] = value
+	}
+
+	return data, nil
+}
+
+// render renders the template by the token values.
+func render(tmpl *template.Template, data map[string]any) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render the template of '%s': %w", tmpl.Name(), err)
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	"github.com/zyy17/o11ybench/pkg/generator/faker"
+)
+
+func TestHTTPSenderRequestTemplates(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests = make(map[string]int)
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		requests[r.URL.RequestURI()+" "+r.Header.Get("X-Scope-OrgID")+" "+r.Header.Get("Content-Type")]++
+	}))
+	defer server.Close()
+
+	cfg := &HTTPConfig{
+		Host:   "127.0.0.1",
+		Port:   server.Listener.Addr().(*net.TCPAddr).Port,
+		URI:    "/v1/logs?db={{ .db }}&table={{ .table | urlquery }}",
+		Method: http.MethodPost,
+		Headers: map[string]string{
+			"X-Scope-OrgID": "{{ .db }}",
+			"Content-Type":  "text/plain",
+		},
+		Tokens: []*RequestTokenConfig{
+			{Name: "db", FakeConfig: &faker.FakeConfig{Kind: faker.FakeDataKindWords, Options: faker.Options{"fixedWords": []string{"tenant-a", "tenant-b"}}}},
+			{Name: "table", Value: "app logs"},
+		},
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+
+	sender, err := newHTTPSender(cfg, &rawEncoder{})
+	if err != nil {
+		t.Fatalf("failed to create the sender: %v", err)
+	}
+	defer sender.close()
+
+	for i := 0; i < 100; i++ {
+		if _, err := sender.send(context.Background(), &worker{}, &generator.GeneratorOutput{Data: []byte("test")}); err != nil {
+			t.Fatalf("failed to send: %v", err)
+		}
+	}
+
+	// The URI and the header of a request share the same token values.
+	a, b := requests["/v1/logs?db=tenant-a&table=app+logs tenant-a text/plain"], requests["/v1/logs?db=tenant-b&table=app+logs tenant-b text/plain"]
+	if a == 0 || b == 0 || a+b != 100 {
+		t.Fatalf("the requests are not fanned out across the tenants: '%v'", requests)
+	}
+}
+
+func TestRequestTemplatesValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		uri     string
+		tokens  []*RequestTokenConfig
+		wantErr bool
+	}{
+		{name: "literal uri", uri: "/v1/logs"},
+		{name: "timestamp", uri: `/v1/logs?table=logs_{{ .Timestamp.Format "20060102" }}`},
+		{name: "fixed value", uri: "/v1/logs?db={{ .db }}", tokens: []*RequestTokenConfig{{Name: "db", Value: "public"}}},
+		{name: "unknown token", uri: "/v1/logs?db={{ .db }}", wantErr: true},
+		{name: "invalid template", uri: "/v1/logs?db={{ .db", tokens: []*RequestTokenConfig{{Name: "db", Value: "public"}}, wantErr: true},
+		{name: "token without the value", uri: "/v1/logs?db={{ .db }}", tokens: []*RequestTokenConfig{{Name: "db"}}, wantErr: true},
+		{name: "duplicate tokens", uri: "/v1/logs", tokens: []*RequestTokenConfig{{Name: "db", Value: "a"}, {Name: "db", Value: "b"}}, wantErr: true},
+		{
+			name:    "unknown fake kind",
+			uri:     "/v1/logs?db={{ .db }}",
+			tokens:  []*RequestTokenConfig{{Name: "db", FakeConfig: &faker.FakeConfig{Kind: "tenant"}}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &HTTPConfig{Host: "localhost", Port: 4000, URI: tt.uri, Method: http.MethodPost, Tokens: tt.tokens}
+			if err := cfg.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,295 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// responseValidator validates the HTTP response of the target by the rules in the config.
+type responseValidator struct {
+	// statusCodes are the accepted ranges of the status codes. The status code is checked by the protocol if it's empty.
+	statusCodes []statusCodeRange
+
+	assertions []*assertion
+
+	// recordsAccepted is the JSON path of the number of the accepted records. It's nil if not set.
+	recordsAccepted jsonPath
+}
+
+// statusCodeRange is the range of the accepted status codes, including both ends.
+type statusCodeRange struct {
+	min, max int
+}
+
+// assertion is the compiled AssertionConfig.
+type assertion struct {
+	cfg   *AssertionConfig
+	path  jsonPath
+	regex *regexp.Regexp
+}
+
+func newResponseValidator(cfg *ResponseConfig) (*responseValidator, error) {
+	v := &responseValidator{}
+	for _, code := range cfg.StatusCodes {
+		r, err := parseStatusCodeRange(code)
+		if err != nil {
+			return nil, err
+		}
+		v.statusCodes = append(v.statusCodes, r)
+	}
+
+	for _, c := range cfg.Assertions {
+		if c.Equals == "" && c.Regex == "" {
+			return nil, fmt.Errorf("one of equals and regex is required for the assertion")
+		}
+
+		a := &assertion{cfg: c}
+		if c.JSONPath != "" {
+			path, err := parseJSONPath(c.JSONPath)
+			if err != nil {
+				return nil, err
+			}
+			a.path = path
+		}
+
+		if c.Regex != "" {
+			regex, err := regexp.Compile(c.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("invalid regex '%s': %w", c.Regex, err)
+			}
+			a.regex = regex
+		}
+		v.assertions = append(v.assertions, a)
+	}
+
+	if cfg.RecordsAccepted != "" {
+		path, err := parseJSONPath(cfg.RecordsAccepted)
+		if err != nil {
+			return nil, err
+		}
+		v.recordsAccepted = path
+	}
+
+	return v, nil
+}
+
+// acceptsStatusCode returns whether the status code is accepted. It's only called if the status codes are set.
+func (v *responseValidator) acceptsStatusCode(code int) bool {
+	for _, r := range v.statusCodes {
+		if code >= r.min && code <= r.max {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkBody runs the assertions on the body and compares the accepted records with the records of the request.
+// It returns the *partialFailureError if part of the records are not accepted.
+func (v *responseValidator) checkBody(body []byte, records int64) error {
+	if len(v.assertions) == 0 && v.recordsAccepted == nil {
+		return nil
+	}
+
+	// The body is only parsed as JSON if any JSON path is used, so the regex can be asserted on the plain text body.
+	var (
+		doc    any
+		docErr error
+		parsed bool
+	)
+	lookup := func(path jsonPath) (any, error) {
+		if !parsed {
+			dec := json.NewDecoder(bytes.NewReader(body))
+			dec.UseNumber()
+			docErr = dec.Decode(&doc)
+			parsed = true
+		}
+		if docErr != nil {
+			return nil, fmt.Errorf("failed to parse the response body as JSON: %w", docErr)
+		}
+
+		value, ok := path.lookup(doc)
+		if !ok {
+			return nil, fmt.Errorf("'%s' is not found in the response body", path)
+		}
+		return value, nil
+	}
+
+	for _, a := range v.assertions {
+		actual := string(body)
+		if a.path != nil {
+			value, err := lookup(a.path)
+			if err != nil {
+				return &classifiedError{class: failureClassAssertion, err: err}
+			}
+			actual = jsonString(value)
+		}
+
+		if a.cfg.Equals != "" && actual != a.cfg.Equals {
+			return &classifiedError{class: failureClassAssertion, err: fmt.Errorf("'%s' of the response is '%s', expected: '%s'", a.target(), actual, a.cfg.Equals)}
+		}
+
+		if a.regex != nil && !a.regex.MatchString(actual) {
+			return &classifiedError{class: failureClassAssertion, err: fmt.Errorf("'%s' of the response '%s' doesn't match '%s'", a.target(), actual, a.regex)}
+		}
+	}
+
+	if v.recordsAccepted != nil {
+		value, err := lookup(v.recordsAccepted)
+		if err != nil {
+			return &classifiedError{class: failureClassAssertion, err: err}
+		}
+
+		number, ok := value.(json.Number)
+		if !ok {
+			return &classifiedError{class: failureClassAssertion, err: fmt.Errorf("'%s' of the response is not a number: '%s'", v.recordsAccepted, jsonString(value))}
+		}
+
+		accepted, err := number.Int64()
+		if err != nil {
+			return &classifiedError{class: failureClassAssertion, err: fmt.Errorf("'%s' of the response is not an integer: '%s'", v.recordsAccepted, number)}
+		}
+
+		if accepted < records {
+			return &partialFailureError{rejected: records - max(accepted, 0), reason: fmt.Sprintf("'%d' of '%d' records are accepted", accepted, records)}
+		}
+	}
+
+	return nil
+}
+
+// target returns the asserted part of the response in the error message.
+func (a *assertion) target() string {
+	if a.path == nil {
+		return "body"
+	}
+
+	return a.cfg.JSONPath
+}
+
+// parseStatusCodeRange parses the status code like `200`, the class like `2xx` or the range like `200-299`.
+func parseStatusCodeRange(s string) (statusCodeRange, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+
+	var r statusCodeRange
+	switch {
+	case len(s) == 3 && strings.HasSuffix(s, "xx") && s[0] >= '1' && s[0] <= '5':
+		class := int(s[0]-'0') * 100
+		r = statusCodeRange{min: class, max: class + 99}
+	case strings.Contains(s, "-"):
+		lower, upper, _ := strings.Cut(s, "-")
+		minCode, err1 := strconv.Atoi(strings.TrimSpace(lower))
+		maxCode, err2 := strconv.Atoi(strings.TrimSpace(upper))
+		if err1 != nil || err2 != nil {
+			return r, fmt.Errorf("invalid status code range: '%s'", s)
+		}
+		r = statusCodeRange{min: minCode, max: maxCode}
+	default:
+		code, err := strconv.Atoi(s)
+		if err != nil {
+			return r, fmt.Errorf("invalid status code: '%s'", s)
+		}
+		r = statusCodeRange{min: code, max: code}
+	}
+
+	if r.min < 100 || r.max > 599 || r.min > r.max {
+		return r, fmt.Errorf("invalid status code: '%s'", s)
+	}
+
+	return r, nil
+}
+
+// jsonPath is the path of a value in the JSON document. Each element is either the key of an object or the index of an array.
+type jsonPath []any
+
+// parseJSONPath parses the path like `errors`, `items[0].status` or `$.partialSuccess.rejectedLogRecords`.
+func parseJSONPath(s string) (jsonPath, error) {
+	rest := strings.TrimPrefix(strings.TrimPrefix(s, "$"), ".")
+	if rest == "" {
+		return nil, fmt.Errorf("invalid JSON path: '%s'", s)
+	}
+
+	var path jsonPath
+	for _, part := range strings.Split(rest, ".") {
+		key, indexes, _ := strings.Cut(part, "[")
+		if key != "" {
+			path = append(path, key)
+		} else if indexes == "" {
+			return nil, fmt.Errorf("invalid JSON path: '%s'", s)
+		}
+
+		if indexes == "" {
+			continue
+		}
+
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			i, err := strconv.Atoi(index)
+			if err != nil || i < 0 || !strings.HasSuffix(indexes, "]") {
+				return nil, fmt.Errorf("invalid JSON path: '%s'", s)
+			}
+			path = append(path, i)
+		}
+	}
+
+	return path, nil
+}
+
+// lookup returns the value at the path in the document decoded by encoding/json.
+func (p jsonPath) lookup(doc any) (any, bool) {
+	value := doc
+	for _, elem := range p {
+		switch elem := elem.(type) {
+		case string:
+			object, ok := value.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if value, ok = object[elem]; !ok {
+				return nil, false
+			}
+		case int:
+			array, ok := value.([]any)
+			if !ok || elem >= len(array) {
+				return nil, false
+			}
+			value = array[elem]
+		}
+	}
+
+	return value, true
+}
+
+func (p jsonPath) String() string {
+	var sb strings.Builder
+	for _, elem := range p {
+		switch elem := elem.(type) {
+		case string:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(elem)
+		case int:
+			fmt.Fprintf(&sb, "[%d]", elem)
+		}
+	}
+
+	return sb.String()
+}
+
+// jsonString returns the string without the quotes, or the JSON of the other values.
+func jsonString(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(data)
+}
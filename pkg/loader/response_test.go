@@ -0,0 +1,221 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+func TestHTTPSenderResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		response *ResponseConfig
+
+		// wantClass is the failure class of the error. It's empty if the request is successful.
+		wantClass    string
+		wantRejected int64
+	}{
+		{name: "2xx by default", status: http.StatusNoContent},
+		{name: "non-2xx by default", status: http.StatusFound, wantClass: "HTTP 302"},
+		{name: "accepted status code", status: http.StatusAccepted, response: &ResponseConfig{StatusCodes: []string{"200", "202"}}},
+		{name: "not accepted status code", status: http.StatusNoContent, response: &ResponseConfig{StatusCodes: []string{"200-201"}}, wantClass: "HTTP 204"},
+		{
+			name:     "json path equals",
+			status:   http.StatusOK,
+			body:     `{"took": 3, "errors": false}`,
+			response: &ResponseConfig{Assertions: []*AssertionConfig{{JSONPath: "errors", Equals: "false"}}},
+		},
+		{
+			name:      "json path not equals",
+			status:    http.StatusOK,
+			body:      `{"took": 3, "errors": true}`,
+			response:  &ResponseConfig{Assertions: []*AssertionConfig{{JSONPath: "errors", Equals: "false"}}},
+			wantClass: failureClassAssertion,
+		},
+		{
+			name:     "json path regex",
+			status:   http.StatusOK,
+			body:     `{"items": [{"status": "created"}]}`,
+			response: &ResponseConfig{Assertions: []*AssertionConfig{{JSONPath: "$.items[0].status", Regex: "^(created|ok)$"}}},
+		},
+		{
+			name:      "json path not found",
+			status:    http.StatusOK,
+			body:      `{"items": []}`,
+			response:  &ResponseConfig{Assertions: []*AssertionConfig{{JSONPath: "items[0].status", Equals: "ok"}}},
+			wantClass: failureClassAssertion,
+		},
+		{
+			name:      "body regex",
+			status:    http.StatusOK,
+			body:      "error: table not found",
+			response:  &ResponseConfig{Assertions: []*AssertionConfig{{Regex: "^ok"}}},
+			wantClass: failureClassAssertion,
+		},
+		{
+			name:     "all records accepted",
+			status:   http.StatusOK,
+			body:     `{"accepted": 10}`,
+			response: &ResponseConfig{RecordsAccepted: "accepted"},
+		},
+		{
+			name:         "part of the records accepted",
+			status:       http.StatusOK,
+			body:         `{"accepted": 7}`,
+			response:     &ResponseConfig{RecordsAccepted: "accepted"},
+			wantClass:    failureClassPartial,
+			wantRejected: 3,
+		},
+		{
+			name:      "records accepted is not a number",
+			status:    http.StatusOK,
+			body:      `{"accepted": "all"}`,
+			response:  &ResponseConfig{RecordsAccepted: "accepted"},
+			wantClass: failureClassAssertion,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := &HTTPConfig{
+				Host:     "127.0.0.1",
+				Port:     server.Listener.Addr().(*net.TCPAddr).Port,
+				URI:      "/api/load",
+				Method:   http.MethodPost,
+				Response: tt.response,
+			}
+			if err := cfg.validate(); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			sender, err := newHTTPSender(cfg, &rawEncoder{})
+			if err != nil {
+				t.Fatalf("failed to create the sender: %v", err)
+			}
+			defer sender.close()
+			sender.records = 10
+
+			_, err = sender.send(context.Background(), &worker{}, &generator.GeneratorOutput{Data: []byte("test")})
+			if tt.wantClass == "" {
+				if err != nil {
+					t.Fatalf("failed to send: %v", err)
+				}
+				return
+			}
+
+			var classifier collector.FailureClassifier
+			if !errors.As(err, &classifier) || classifier.FailureClass() != tt.wantClass {
+				t.Fatalf("unexpected error: '%v', expected the failure class: '%s'", err, tt.wantClass)
+			}
+
+			var partialErr *partialFailureError
+			if errors.As(err, &partialErr) && partialErr.rejected != tt.wantRejected {
+				t.Fatalf("unexpected rejected records: '%d', expected: '%d'", partialErr.rejected, tt.wantRejected)
+			}
+		})
+	}
+}
+
+func TestHTTPSenderResponseElasticsearch(t *testing.T) {
+	bulkErrors := `{"errors": true, "items": [{"create": {"status": 201}}, {"create": {"status": 400, "error": {"type": "mapper_parsing_exception"}}}]}`
+
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		response *ResponseConfig
+
+		// wantClass is the failure class of the error. It's empty if the request is successful.
+		wantClass string
+	}{
+		{name: "bulk item errors", status: http.StatusOK, body: bulkErrors, wantClass: failureClassPartial},
+		{name: "non-200 by default", status: http.StatusCreated, body: `{"errors": false, "items": []}`, wantClass: "HTTP 201"},
+		{name: "accepted status code", status: http.StatusCreated, body: `{"errors": false, "items": []}`, response: &ResponseConfig{StatusCodes: []string{"2xx"}}},
+		{name: "bulk item errors with accepted status code", status: http.StatusCreated, body: bulkErrors, response: &ResponseConfig{StatusCodes: []string{"2xx"}}, wantClass: failureClassPartial},
+		{name: "not accepted status code", status: http.StatusInternalServerError, body: bulkErrors, response: &ResponseConfig{StatusCodes: []string{"2xx"}}, wantClass: "HTTP 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			cfg := &HTTPConfig{
+				Host:     "127.0.0.1",
+				Port:     server.Listener.Addr().(*net.TCPAddr).Port,
+				URI:      "/_bulk",
+				Method:   http.MethodPost,
+				Response: tt.response,
+			}
+
+			encoder, err := newElasticsearchEncoder(ElasticsearchConfig{}.defaults())
+			if err != nil {
+				t.Fatalf("failed to create encoder: %v", err)
+			}
+
+			sender, err := newHTTPSender(cfg, encoder)
+			if err != nil {
+				t.Fatalf("failed to create the sender: %v", err)
+			}
+			defer sender.close()
+
+			output := &generator.GeneratorOutput{Logs: []*logstypes.LogRecord{{Line: []byte(`{"level":"info"}`)}, {Line: []byte(`{"level":"error"}`)}}}
+			_, err = sender.send(context.Background(), &worker{}, output)
+			if tt.wantClass == "" {
+				if err != nil {
+					t.Fatalf("failed to send: %v", err)
+				}
+				return
+			}
+
+			var classifier collector.FailureClassifier
+			if !errors.As(err, &classifier) || classifier.FailureClass() != tt.wantClass {
+				t.Fatalf("unexpected error: '%v', expected the failure class: '%s'", err, tt.wantClass)
+			}
+		})
+	}
+}
+
+func TestResponseConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		response *ResponseConfig
+		wantErr  bool
+	}{
+		{name: "status codes", response: &ResponseConfig{StatusCodes: []string{"200", "2xx", "200-299", "4XX"}}},
+		{name: "invalid status code", response: &ResponseConfig{StatusCodes: []string{"ok"}}, wantErr: true},
+		{name: "invalid status code class", response: &ResponseConfig{StatusCodes: []string{"6xx"}}, wantErr: true},
+		{name: "reversed status code range", response: &ResponseConfig{StatusCodes: []string{"299-200"}}, wantErr: true},
+		{name: "assertion without the expectation", response: &ResponseConfig{Assertions: []*AssertionConfig{{JSONPath: "errors"}}}, wantErr: true},
+		{name: "invalid regex", response: &ResponseConfig{Assertions: []*AssertionConfig{{Regex: "("}}}, wantErr: true},
+		{name: "invalid json path", response: &ResponseConfig{RecordsAccepted: "items[first]"}, wantErr: true},
+		{name: "json path with indexes", response: &ResponseConfig{RecordsAccepted: "$.items[0][1].accepted"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newResponseValidator(tt.response)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
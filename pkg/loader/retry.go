@@ -0,0 +1,121 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// retrier decides whether the failed requests are retried and how long to wait before the retries.
+type retrier struct {
+	cfg *RetryConfig
+
+	// retryableStatusCodes is the set of the retryable status codes.
+	retryableStatusCodes map[int]bool
+}
+
+func newRetrier(cfg *RetryConfig) *retrier {
+	codes := cfg.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = RetryConfig{}.defaults().RetryableStatusCodes
+	}
+
+	r := &retrier{cfg: cfg, retryableStatusCodes: make(map[int]bool, len(codes))}
+	for _, code := range codes {
+		r.retryableStatusCodes[code] = true
+	}
+
+	return r
+}
+
+// retryable returns whether the error is retryable and the delay requested by the target.
+// The transport errors are only retryable if they are transient, see transientError.
+func (r *retrier) retryable(err error) (bool, time.Duration) {
+	var codeErr *statusCodeError
+	if errors.As(err, &codeErr) {
+		return r.retryableStatusCodes[codeErr.code], codeErr.retryAfter
+	}
+
+	if st, ok := status.FromError(err); ok {
+		code, ok := grpcStatusCodes[st.Code()]
+		if !ok || !r.retryableStatusCodes[code] {
+			return false, 0
+		}
+
+		for _, detail := range st.Details() {
+			if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+				return true, info.GetRetryDelay().AsDuration()
+			}
+		}
+		return true, 0
+	}
+
+	return transientError(err), 0
+}
+
+// transientError returns whether the transport error is transient: the timeouts, the refused or reset connections,
+// and the reused connections closed by the target. The other errors, for example, the TLS handshake and certificate errors
+// and the invalid URLs, fail again in the same way, so they are not retried.
+func transientError(err error) bool {
+	var staleErr *staleConnectionError
+	if errors.As(err, &staleErr) {
+		return true
+	}
+
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// grpcStatusCodes maps the retryable gRPC status codes to the equivalent HTTP status codes, so the same retryable status codes work for both.
+var grpcStatusCodes = map[codes.Code]int{
+	codes.ResourceExhausted: http.StatusTooManyRequests,
+	codes.Unavailable:       http.StatusServiceUnavailable,
+	codes.DeadlineExceeded:  http.StatusGatewayTimeout,
+}
+
+// backoff returns the delay before the nth retry, which starts from 1. The exponential backoff is randomized by the jitter.
+// The delay requested by the target is used instead if it's longer and `respectRetryAfter` is enabled, but it's capped by `maxBackoff`,
+// so a target can't hold the workers for a long time, for example, by `Retry-After: 3600`.
+func (r *retrier) backoff(n int, retryAfter time.Duration) time.Duration {
+	delay := float64(r.cfg.InitialBackoff) * math.Pow(r.cfg.Multiplier, float64(n-1))
+	delay = min(delay, float64(r.cfg.MaxBackoff))
+	delay *= 1 + r.cfg.Jitter*(2*rand.Float64()-1)
+
+	if (r.cfg.RespectRetryAfter == nil || *r.cfg.RespectRetryAfter) && retryAfter > time.Duration(delay) {
+		return min(retryAfter, r.cfg.MaxBackoff)
+	}
+
+	return time.Duration(delay)
+}
+
+// parseRetryAfter parses the `Retry-After` header, which is either the delay in seconds or the HTTP date. It returns 0 if the header is not set or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+
+	return 0
+}
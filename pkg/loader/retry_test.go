@@ -0,0 +1,254 @@
+package loader
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestLoaderRetry(t *testing.T) {
+	tests := []struct {
+		name string
+
+		// status is the status code of the failed attempts, and failures is the number of the failed attempts before each success.
+		// The target always fails if failures is negative.
+		status   int
+		failures int
+
+		wantSuccess        int64
+		wantRetriedSuccess int64
+		wantRetries        int64
+		wantExhausted      int64
+	}{
+		{name: "success after retries", status: http.StatusServiceUnavailable, failures: 2, wantSuccess: 5, wantRetriedSuccess: 5, wantRetries: 10},
+		{name: "success at the first attempt", status: http.StatusServiceUnavailable, failures: 0, wantSuccess: 5},
+		{name: "exhausted retries", status: http.StatusTooManyRequests, failures: -1, wantRetries: 10, wantExhausted: 5},
+		{name: "not retryable", status: http.StatusBadRequest, failures: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int64
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				if tt.failures < 0 || n%int64(tt.failures+1) != 0 {
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer server.Close()
+
+			retry := RetryConfig{}.defaults()
+			retry.InitialBackoff = 10 * time.Millisecond
+
+			// The single worker makes the requests one by one, so the failed attempts of the target match the attempts of each request.
+			cfg := &Config{
+				Rate:     5,
+				Workers:  1,
+				Duration: time.Second,
+				Retry:    retry,
+				Logs: &LogsGeneratorConfig{
+					RecordsPerRequest: 1,
+				},
+				HTTP: &HTTPConfig{
+					Host:   "127.0.0.1",
+					Port:   server.Listener.Addr().(*net.TCPAddr).Port,
+					URI:    "/api/load",
+					Method: "POST",
+				},
+			}
+
+			collector := collector.New()
+			loader, err := New(cfg, &mockGenerator{}, collector)
+			if err != nil {
+				t.Fatalf("failed to create loader: %v", err)
+			}
+
+			if err := loader.Start(); err != nil {
+				t.Fatalf("failed to start loader: %v", err)
+			}
+
+			if collector.SuccessCount() != tt.wantSuccess || collector.FailureCount() != 5-tt.wantSuccess {
+				t.Fatalf("unexpected success: '%d' and failure: '%d', expected success: '%d'", collector.SuccessCount(), collector.FailureCount(), tt.wantSuccess)
+			}
+
+			if collector.RetriedSuccessCount() != tt.wantRetriedSuccess || collector.FirstAttemptSuccessCount() != tt.wantSuccess-tt.wantRetriedSuccess {
+				t.Fatalf("unexpected retried success: '%d' and first attempt success: '%d'", collector.RetriedSuccessCount(), collector.FirstAttemptSuccessCount())
+			}
+
+			if collector.RetriesCount() != tt.wantRetries || collector.ExhaustedRetriesCount() != tt.wantExhausted {
+				t.Fatalf("unexpected retries: '%d' and exhausted retries: '%d', expected: '%d' and '%d'", collector.RetriesCount(), collector.ExhaustedRetriesCount(), tt.wantRetries, tt.wantExhausted)
+			}
+
+			// The same payload `test` is sent by all the attempts.
+			if collector.RawBytes() != 4*attempts.Load() {
+				t.Fatalf("actual raw bytes: '%d', expected raw bytes: '%d'", collector.RawBytes(), 4*attempts.Load())
+			}
+		})
+	}
+}
+
+func TestLoaderRetryStopTime(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	retry := RetryConfig{}.defaults()
+	retry.MaxBackoff = time.Hour
+
+	cfg := &Config{
+		Rate:     5,
+		Workers:  1,
+		Duration: 500 * time.Millisecond,
+		Retry:    retry,
+		Logs: &LogsGeneratorConfig{
+			RecordsPerRequest: 1,
+		},
+		HTTP: &HTTPConfig{
+			Host:   "127.0.0.1",
+			Port:   server.Listener.Addr().(*net.TCPAddr).Port,
+			URI:    "/api/load",
+			Method: "POST",
+		},
+	}
+
+	collector := collector.New()
+	loader, err := New(cfg, &mockGenerator{}, collector)
+	if err != nil {
+		t.Fatalf("failed to create loader: %v", err)
+	}
+
+	start := time.Now()
+	if err := loader.Start(); err != nil {
+		t.Fatalf("failed to start loader: %v", err)
+	}
+
+	// The retries that would wait past the stop time are given up, so the test is not extended by the `Retry-After`.
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("the test is extended by the retries: '%s'", elapsed)
+	}
+
+	if collector.RetriesCount() != 0 || collector.ExhaustedRetriesCount() != 0 || collector.AbandonedRetriesCount() != collector.FailureCount() || collector.FailureCount() == 0 {
+		t.Fatalf("unexpected retries: '%d', exhausted retries: '%d', abandoned retries: '%d' and failures: '%d'",
+			collector.RetriesCount(), collector.ExhaustedRetriesCount(), collector.AbandonedRetriesCount(), collector.FailureCount())
+	}
+}
+
+func TestRetrierBackoff(t *testing.T) {
+	respectRetryAfter := false
+	cfg := &RetryConfig{MaxAttempts: 5, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond, Multiplier: 2}
+	r := newRetrier(cfg)
+
+	tests := []struct {
+		n          int
+		retryAfter time.Duration
+		expected   time.Duration
+	}{
+		{n: 1, expected: 100 * time.Millisecond},
+		{n: 2, expected: 200 * time.Millisecond},
+		{n: 3, expected: 300 * time.Millisecond},
+		{n: 2, retryAfter: 250 * time.Millisecond, expected: 250 * time.Millisecond},
+		{n: 2, retryAfter: time.Hour, expected: 300 * time.Millisecond},
+		{n: 2, retryAfter: 50 * time.Millisecond, expected: 200 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		if backoff := r.backoff(tt.n, tt.retryAfter); backoff != tt.expected {
+			t.Fatalf("unexpected backoff of the retry %d with the retry after '%s': '%s', expected: '%s'", tt.n, tt.retryAfter, backoff, tt.expected)
+		}
+	}
+
+	cfg.RespectRetryAfter = &respectRetryAfter
+	if backoff := r.backoff(1, time.Second); backoff != 100*time.Millisecond {
+		t.Fatalf("the retry after should be ignored: '%s'", backoff)
+	}
+
+	cfg.RespectRetryAfter = nil
+	cfg.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if backoff := r.backoff(1, 0); backoff < 50*time.Millisecond || backoff > 150*time.Millisecond {
+			t.Fatalf("the backoff with the jitter is out of range: '%s'", backoff)
+		}
+	}
+}
+
+func TestRetrierRetryable(t *testing.T) {
+	throttled, err := status.New(codes.ResourceExhausted, "throttled").WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(2 * time.Second)})
+	if err != nil {
+		t.Fatalf("failed to create the status: %v", err)
+	}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryable  bool
+		wantRetryAfter time.Duration
+	}{
+		{name: "HTTP 503 with retry after", err: &statusCodeError{code: http.StatusServiceUnavailable, retryAfter: time.Second}, wantRetryable: true, wantRetryAfter: time.Second},
+		{name: "HTTP 400", err: &statusCodeError{code: http.StatusBadRequest}},
+		{name: "gRPC unavailable", err: classifyGRPCError(status.Error(codes.Unavailable, "unavailable")), wantRetryable: true},
+		{name: "gRPC resource exhausted with retry info", err: throttled.Err(), wantRetryable: true, wantRetryAfter: 2 * time.Second},
+		{name: "gRPC invalid argument", err: status.Error(codes.InvalidArgument, "invalid")},
+		{name: "connection refused", err: &url.Error{Op: "Post", URL: "http://localhost", Err: &net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}}, wantRetryable: true},
+		{name: "connection reset", err: &net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}, wantRetryable: true},
+		{name: "timeout", err: &url.Error{Op: "Post", URL: "http://localhost", Err: context.DeadlineExceeded}, wantRetryable: true},
+		{name: "EOF on a reused connection", err: &staleConnectionError{err: &url.Error{Op: "Post", URL: "http://localhost", Err: io.EOF}}, wantRetryable: true},
+		{name: "EOF on a new connection", err: &url.Error{Op: "Post", URL: "http://localhost", Err: io.EOF}},
+		{name: "TLS certificate", err: &url.Error{Op: "Post", URL: "https://localhost", Err: &tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}}},
+		{name: "TLS handshake", err: &url.Error{Op: "Post", URL: "https://localhost", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}}},
+		{name: "invalid URL", err: &url.Error{Op: "parse", URL: "://localhost", Err: errors.New("missing protocol scheme")}},
+		{name: "DNS", err: &url.Error{Op: "Post", URL: "http://unknown.invalid", Err: &net.DNSError{Err: "no such host", Name: "unknown.invalid", IsNotFound: true}}},
+		{name: "encode error", err: &classifiedError{class: failureClassEncode, err: http.ErrBodyNotAllowed}},
+	}
+
+	r := newRetrier(RetryConfig{}.defaults())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, retryAfter := r.retryable(tt.err)
+			if retryable != tt.wantRetryable || retryAfter != tt.wantRetryAfter {
+				t.Fatalf("unexpected retryable: '%v' and retry after: '%s', expected: '%v' and '%s'", retryable, retryAfter, tt.wantRetryable, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{value: "", expected: 0},
+		{value: "3", expected: 3 * time.Second},
+		{value: "-1", expected: 0},
+		{value: now.Add(5 * time.Second).Format(http.TimeFormat), expected: 5 * time.Second},
+		{value: now.Add(-5 * time.Second).Format(http.TimeFormat), expected: 0},
+		{value: "invalid", expected: 0},
+	}
+
+	for _, tt := range tests {
+		if actual := parseRetryAfter(tt.value, now); actual != tt.expected {
+			t.Fatalf("unexpected retry after of '%s': '%s', expected: '%s'", tt.value, actual, tt.expected)
+		}
+	}
+}
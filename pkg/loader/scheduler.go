@@ -0,0 +1,88 @@
+package loader
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// profileResolution is the time step to integrate the rate of the load profile.
+const profileResolution = time.Millisecond
+
+// scheduler computes the intended start times of the requests. The intended start times only depend on the rate and the arrival,
+// so the requests are made on the same timeline regardless of how fast the target responds, which avoids the coordinated omission.
+type scheduler struct {
+	start   time.Time
+	rate    float64
+	profile *ProfileConfig
+	arrival Arrival
+	rand    *rand.Rand
+
+	// count is the number of the scheduled requests.
+	count int64
+
+	// elapsed is the offset of the next request from the start. It's not used by the constant arrival without the profile.
+	elapsed time.Duration
+}
+
+// newScheduler creates a scheduler with the fixed rate, or with the rate that changes over time if the profile is set.
+func newScheduler(start time.Time, rate float64, profile *ProfileConfig, arrival Arrival) *scheduler {
+	return &scheduler{
+		start:   start,
+		rate:    rate,
+		profile: profile,
+		arrival: arrival,
+		rand:    rand.New(rand.NewSource(start.UnixNano())),
+	}
+}
+
+// next returns the intended start time of the next request.
+func (s *scheduler) next() time.Time {
+	defer func() { s.count++ }()
+
+	// The first request starts immediately unless the rate of the profile is 0 at the start.
+	if s.count == 0 && (s.profile == nil || s.profile.rateAt(0) > 0) {
+		return s.start
+	}
+
+	// The number of the expected requests between two requests is 1 for the constant arrival, and exponentially distributed for the Poisson arrival.
+	requests := 1.0
+	if s.arrival == ArrivalPoisson {
+		requests = s.rand.ExpFloat64()
+	}
+
+	if s.profile != nil {
+		s.elapsed = s.advance(s.elapsed, requests)
+		return s.start.Add(s.elapsed)
+	}
+
+	if s.arrival == ArrivalPoisson {
+		s.elapsed += time.Duration(requests / s.rate * float64(time.Second))
+		return s.start.Add(s.elapsed)
+	}
+
+	// Compute the offset from the start instead of accumulating the interval to avoid the rounding drift.
+	return s.start.Add(time.Duration(float64(s.count) / s.rate * float64(time.Second)))
+}
+
+// advance integrates the rate of the profile from the elapsed time until the given number of the requests are expected.
+// It returns the elapsed time when it's done, or the time beyond the profile if the profile ends before that.
+func (s *scheduler) advance(elapsed time.Duration, requests float64) time.Duration {
+	end := s.profile.duration()
+	for elapsed < end {
+		rate := s.profile.rateAt(elapsed)
+
+		// Within a step, the rate is treated as constant.
+		if rate > 0 {
+			// Round the interval to avoid the requests at the boundary of the stages falling into the previous stage due to the floating point error.
+			if interval := time.Duration(math.Round(requests / rate * float64(time.Second))); interval <= profileResolution {
+				return elapsed + interval
+			}
+		}
+
+		requests -= rate * profileResolution.Seconds()
+		elapsed += profileResolution
+	}
+
+	return end
+}
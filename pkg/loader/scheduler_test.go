@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSchedulerConstant(t *testing.T) {
+	tests := []struct {
+		rate     float64
+		expected []time.Duration
+	}{
+		{rate: 4, expected: []time.Duration{0, 250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond, time.Second}},
+		{rate: 0.5, expected: []time.Duration{0, 2 * time.Second, 4 * time.Second}},
+		{rate: 3, expected: []time.Duration{0, 333333333, 666666666, time.Second}},
+	}
+
+	for _, tt := range tests {
+		start := time.Now()
+		s := newScheduler(start, tt.rate, nil, ArrivalConstant)
+		for i, expected := range tt.expected {
+			if actual := s.next().Sub(start); actual != expected {
+				t.Fatalf("rate '%f': the offset of request %d: '%s', expected: '%s'", tt.rate, i, actual, expected)
+			}
+		}
+	}
+}
+
+func TestSchedulerPoisson(t *testing.T) {
+	const (
+		rate     = 100.0
+		requests = 10000
+	)
+
+	start := time.Now()
+	s := newScheduler(start, rate, nil, ArrivalPoisson)
+
+	var (
+		prev      = s.next()
+		intervals = make([]float64, 0, requests)
+	)
+	if !prev.Equal(start) {
+		t.Fatalf("the first request should start immediately")
+	}
+
+	for i := 0; i < requests; i++ {
+		next := s.next()
+		if next.Before(prev) {
+			t.Fatalf("the intended start times are not monotonic")
+		}
+		intervals = append(intervals, next.Sub(prev).Seconds())
+		prev = next
+	}
+
+	// The mean and the standard deviation of the exponential distribution are both 1/rate.
+	var sum, sumSquares float64
+	for _, interval := range intervals {
+		sum += interval
+	}
+	mean := sum / requests
+	for _, interval := range intervals {
+		sumSquares += (interval - mean) * (interval - mean)
+	}
+	stddev := math.Sqrt(sumSquares / requests)
+
+	delta := 0.1 / rate
+	if math.Abs(mean-1/rate) > delta {
+		t.Fatalf("actual mean interval: '%f', expected: '%f', delta: '%f'", mean, 1/rate, delta)
+	}
+
+	if math.Abs(stddev-1/rate) > delta {
+		t.Fatalf("actual stddev of intervals: '%f', expected: '%f', delta: '%f'", stddev, 1/rate, delta)
+	}
+}
@@ -0,0 +1,99 @@
+package loader
+
+import (
+	"context"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// sender sends the generated data to the target.
+type sender interface {
+	// send sends the generated data to the target. The worker is the worker that makes the request.
+	// The result is returned if the data is sent, even if the target rejects it.
+	send(ctx context.Context, w *worker, output *generator.GeneratorOutput) (*sendResult, error)
+
+	// close releases the resources of the sender.
+	close() error
+}
+
+// preparedSender sends the payloads that are encoded and compressed ahead, so the pool of the payloads can skip the encoding and the compression of each request.
+type preparedSender interface {
+	// prepare encodes and compresses the generated data into the payload of the request.
+	prepare(output *generator.GeneratorOutput) (*preparedPayload, error)
+
+	// sendPrepared sends the prepared payload. The payload is not modified, so it can be sent by the workers concurrently.
+	sendPrepared(ctx context.Context, w *worker, p *preparedPayload) (*sendResult, error)
+}
+
+// preparedPayload is the encoded and compressed payload of a request.
+type preparedPayload struct {
+	// data is the body of the request.
+	data []byte
+
+	// contentType and headers are the content type and the extra headers required by the protocol.
+	contentType string
+	headers     map[string]string
+
+	// rawSize is the size of the encoded data before the compression.
+	rawSize int64
+
+	// compressTime is the time spent on the compression. It's 0 for the payloads of the pool, which are compressed before the run.
+	compressTime time.Duration
+}
+
+// sendResult is the size of the data sent to the target.
+type sendResult struct {
+	// rawBytes is the size of the encoded data before the compression.
+	rawBytes int64
+
+	// wireBytes is the size of the data sent on the wire, including the compression and the framing of the protocol.
+	wireBytes int64
+
+	// compressTime is the time spent on the compression of the request.
+	compressTime time.Duration
+
+	// newConnections and reusedConnections are the number of the new and the reused connections of the HTTP requests.
+	newConnections    int64
+	reusedConnections int64
+}
+
+// add adds the result of another attempt of the request. The result can be nil if nothing is sent.
+func (r *sendResult) add(other *sendResult) {
+	if other == nil {
+		return
+	}
+
+	r.rawBytes += other.rawBytes
+	r.wireBytes += other.wireBytes
+	r.compressTime += other.compressTime
+	r.newConnections += other.newConnections
+	r.reusedConnections += other.reusedConnections
+}
+
+// newSender creates a new sender by the target in the config.
+func newSender(cfg *Config, encoder Encoder) (sender, error) {
+	if cfg.GRPC != nil {
+		return newGRPCSender(cfg.GRPC, encoder)
+	}
+
+	if cfg.Syslog != nil {
+		return newSyslogSender(cfg.Syslog)
+	}
+
+	if cfg.Forward != nil {
+		return newForwardSender(cfg.Forward), nil
+	}
+
+	if cfg.File != nil {
+		return newFileSender(cfg.File)
+	}
+
+	sender, err := newHTTPSender(cfg.HTTP, encoder)
+	if err != nil {
+		return nil, err
+	}
+	sender.records = cfg.recordsPerRequest()
+
+	return sender, nil
+}
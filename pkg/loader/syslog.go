@@ -0,0 +1,118 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+// syslogSender sends the generated logs to the syslog receiver. Each worker has its own connection.
+type syslogSender struct {
+	cfg       *SyslogConfig
+	address   string
+	tlsConfig *tls.Config
+	conns     *workerConns
+}
+
+var _ sender = &syslogSender{}
+
+func newSyslogSender(cfg *SyslogConfig) (*syslogSender, error) {
+	s := &syslogSender{
+		cfg:     cfg,
+		address: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+	}
+	s.conns = newWorkerConns(s.dial)
+
+	if cfg.Transport == "tls" {
+		tlsCfg := cfg.TLS
+		if tlsCfg == nil {
+			tlsCfg = &TLSConfig{}
+		}
+
+		tlsConfig, err := tlsCfg.tlsConfig(cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		s.tlsConfig = tlsConfig
+	}
+
+	return s, nil
+}
+
+func (s *syslogSender) send(_ context.Context, w *worker, output *generator.GeneratorOutput) (*sendResult, error) {
+	if len(output.Logs) == 0 {
+		return nil, fmt.Errorf("no logs are generated for syslog")
+	}
+
+	conn, err := s.conns.get(w.id)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.cfg.Timeout > 0 {
+		if err := conn.SetWriteDeadline(time.Now().Add(s.cfg.Timeout)); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &sendResult{}
+	for _, log := range output.Logs {
+		result.rawBytes += int64(len(log.Line))
+	}
+
+	if s.cfg.Transport == "udp" {
+		// Each message is a datagram.
+		for _, log := range output.Logs {
+			if _, err := conn.Write(log.Line); err != nil {
+				s.conns.reset(w.id)
+				return nil, err
+			}
+		}
+		result.wireBytes = result.rawBytes
+
+		return result, nil
+	}
+
+	var buf bytes.Buffer
+	for _, log := range output.Logs {
+		if s.cfg.Framing == SyslogFramingOctetCounting {
+			buf.WriteString(strconv.Itoa(len(log.Line)))
+			buf.WriteByte(' ')
+			buf.Write(log.Line)
+		} else {
+			buf.Write(log.Line)
+			buf.WriteByte('\n')
+		}
+	}
+
+	if _, err := conn.Write(buf.Bytes()); err != nil {
+		s.conns.reset(w.id)
+		return nil, err
+	}
+	result.wireBytes = int64(buf.Len())
+
+	return result, nil
+}
+
+func (s *syslogSender) close() error {
+	return s.conns.close()
+}
+
+func (s *syslogSender) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: s.cfg.Timeout}
+
+	switch s.cfg.Transport {
+	case "udp", "tcp":
+		return dialer.Dial(s.cfg.Transport, s.address)
+	case "tls":
+		return tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+	}
+
+	return nil, fmt.Errorf("invalid transport: '%s'", s.cfg.Transport)
+}
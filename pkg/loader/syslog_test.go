@@ -0,0 +1,171 @@
+package loader
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+	logstypes "github.com/zyy17/o11ybench/pkg/generator/logs/types"
+)
+
+var testSyslogLines = []string{
+	"<34>1 2025-03-23T00:00:00.000Z example.com su 1234 ID47 - 'su root' failed",
+	"<165>1 2025-03-23T00:00:00.000Z example.com app 5678 ID48 - hello world",
+}
+
+func testSyslogOutput() *generator.GeneratorOutput {
+	output := &generator.GeneratorOutput{}
+	for _, line := range testSyslogLines {
+		output.Logs = append(output.Logs, &logstypes.LogRecord{Line: []byte(line)})
+	}
+
+	return output
+}
+
+func TestSyslogSender(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport string
+		framing   string
+	}{
+		{name: "tcp with octet-counting", transport: "tcp", framing: SyslogFramingOctetCounting},
+		{name: "tcp with non-transparent", transport: "tcp", framing: SyslogFramingNonTransparent},
+		{name: "tls with octet-counting", transport: "tls", framing: SyslogFramingOctetCounting},
+		{name: "udp", transport: "udp"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := SyslogConfig{}.defaults()
+			cfg.Host = "127.0.0.1"
+			cfg.Transport = tt.transport
+			if tt.framing != "" {
+				cfg.Framing = tt.framing
+			}
+
+			received := make(chan []string, 1)
+			switch tt.transport {
+			case "udp":
+				conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("failed to listen: %v", err)
+				}
+				defer conn.Close()
+				cfg.Port = conn.LocalAddr().(*net.UDPAddr).Port
+
+				go func() {
+					var messages []string
+					buf := make([]byte, 65536)
+					for len(messages) < len(testSyslogLines) {
+						n, _, err := conn.ReadFrom(buf)
+						if err != nil {
+							break
+						}
+						messages = append(messages, string(buf[:n]))
+					}
+					received <- messages
+				}()
+			default:
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				if err != nil {
+					t.Fatalf("failed to listen: %v", err)
+				}
+
+				if tt.transport == "tls" {
+					listener, cfg.TLS = testTLSListener(t, listener)
+				}
+				defer listener.Close()
+				cfg.Port = listener.Addr().(*net.TCPAddr).Port
+
+				go func() {
+					conn, err := listener.Accept()
+					if err != nil {
+						received <- nil
+						return
+					}
+					defer conn.Close()
+					received <- readSyslogMessages(bufio.NewReader(conn), cfg.Framing, len(testSyslogLines))
+				}()
+			}
+
+			sender, err := newSyslogSender(cfg)
+			if err != nil {
+				t.Fatalf("failed to create syslog sender: %v", err)
+			}
+			defer sender.close()
+
+			if _, err := sender.send(context.Background(), &worker{id: 0}, testSyslogOutput()); err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			select {
+			case messages := <-received:
+				if strings.Join(messages, "|") != strings.Join(testSyslogLines, "|") {
+					t.Fatalf("unexpected messages: '%v'", messages)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout to receive the messages")
+			}
+		})
+	}
+}
+
+func readSyslogMessages(reader *bufio.Reader, framing string, count int) []string {
+	var messages []string
+	for len(messages) < count {
+		if framing == SyslogFramingNonTransparent {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return messages
+			}
+			messages = append(messages, strings.TrimSuffix(line, "\n"))
+			continue
+		}
+
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return messages
+		}
+
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return messages
+		}
+
+		message := make([]byte, n)
+		if _, err := io.ReadFull(reader, message); err != nil {
+			return messages
+		}
+		messages = append(messages, string(message))
+	}
+
+	return messages
+}
+
+// testTLSListener wraps the listener with the certificate of the httptest TLS server, and returns the TLS config that trusts the certificate.
+func testTLSListener(t *testing.T, listener net.Listener) (net.Listener, *TLSConfig) {
+	server := httptest.NewUnstartedServer(http.NotFoundHandler())
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, ca, 0644); err != nil {
+		t.Fatalf("failed to write the CA file: %v", err)
+	}
+
+	// The certificate of the httptest server is issued for `example.com`.
+	return tls.NewListener(listener, server.TLS), &TLSConfig{CAFile: caFile, ServerName: "example.com"}
+}
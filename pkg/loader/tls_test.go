@@ -0,0 +1,216 @@
+package loader
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+func TestHTTPSenderTLS(t *testing.T) {
+	dir := t.TempDir()
+	clientCA, certFile, keyFile := testClientCertificate(t, dir)
+
+	tests := []struct {
+		name        string
+		requireMTLS bool
+		tls         func(caFile string) *TLSConfig
+		wantErr     string
+	}{
+		{
+			name: "https with the CA file",
+			tls:  func(caFile string) *TLSConfig { return &TLSConfig{CAFile: caFile, ServerName: "example.com"} },
+		},
+		{
+			name: "https with insecure skip verify",
+			tls:  func(string) *TLSConfig { return &TLSConfig{InsecureSkipVerify: true} },
+		},
+		{
+			name:    "https with the unknown CA",
+			tls:     func(string) *TLSConfig { return &TLSConfig{ServerName: "example.com"} },
+			wantErr: "certificate",
+		},
+		{
+			name:    "https with the wrong server name",
+			tls:     func(caFile string) *TLSConfig { return &TLSConfig{CAFile: caFile, ServerName: "wrong.example.org"} },
+			wantErr: "certificate",
+		},
+		{
+			name:        "mutual tls",
+			requireMTLS: true,
+			tls: func(caFile string) *TLSConfig {
+				return &TLSConfig{CAFile: caFile, ServerName: "example.com", CertFile: certFile, KeyFile: keyFile}
+			},
+		},
+		{
+			name:        "mutual tls without the client certificate",
+			requireMTLS: true,
+			tls:         func(caFile string) *TLSConfig { return &TLSConfig{CAFile: caFile, ServerName: "example.com"} },
+			wantErr:     "certificate",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var received []byte
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.requireMTLS && len(r.TLS.PeerCertificates) == 0 {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				received = []byte(r.URL.Path)
+			}))
+			if tt.requireMTLS {
+				server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCA}
+			}
+			server.StartTLS()
+			defer server.Close()
+
+			caFile := filepath.Join(t.TempDir(), "ca.pem")
+			if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644); err != nil {
+				t.Fatalf("failed to write the CA file: %v", err)
+			}
+
+			port, err := strconv.Atoi(server.URL[strings.LastIndex(server.URL, ":")+1:])
+			if err != nil {
+				t.Fatalf("failed to parse the port: %v", err)
+			}
+
+			cfg := HTTPConfig{}.defaults()
+			cfg.Scheme = "https"
+			cfg.Host = "127.0.0.1"
+			cfg.Port = port
+			cfg.URI = "/ingest"
+			cfg.Method = http.MethodPost
+			cfg.TLS = tt.tls(caFile)
+			if err := cfg.validate(); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			sender, err := newHTTPSender(cfg, &rawEncoder{})
+			if err != nil {
+				t.Fatalf("failed to create the sender: %v", err)
+			}
+			defer sender.close()
+
+			_, err = sender.send(context.Background(), &worker{}, &generator.GeneratorOutput{Data: []byte("hello")})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("unexpected error: '%v', expected: '%s'", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to send: %v", err)
+			}
+
+			if string(received) != "/ingest" {
+				t.Fatalf("unexpected request path: '%s'", received)
+			}
+		})
+	}
+}
+
+func TestHTTPConfigScheme(t *testing.T) {
+	tests := []struct {
+		name    string
+		scheme  string
+		tls     *TLSConfig
+		wantErr bool
+	}{
+		{name: "http", scheme: "http"},
+		{name: "https", scheme: "https"},
+		{name: "https with tls", scheme: "https", tls: &TLSConfig{InsecureSkipVerify: true}},
+		{name: "http with tls", scheme: "http", tls: &TLSConfig{}, wantErr: true},
+		{name: "invalid scheme", scheme: "ftp", wantErr: true},
+		{name: "tls without the scheme", tls: &TLSConfig{}},
+		{name: "cert without key", scheme: "https", tls: &TLSConfig{CertFile: "cert.pem"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &HTTPConfig{Scheme: tt.scheme, Host: "localhost", Port: 4000, URI: "/", Method: http.MethodPost, TLS: tt.tls}
+			if err := cfg.validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: '%v', wantErr: '%v'", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// testClientCertificate creates a CA and a client certificate issued by the CA. It returns the CA pool and the paths of the client certificate and key.
+func testClientCertificate(t *testing.T, dir string) (*x509.CertPool, string, string) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the CA key: %v", err)
+	}
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "o11ybench test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create the CA certificate: %v", err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatalf("failed to parse the CA certificate: %v", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate the client key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "o11ybench"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("failed to create the client certificate: %v", err)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal the client key: %v", err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatalf("failed to write the client certificate: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("failed to write the client key: %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	return pool, certFile, keyFile
+}
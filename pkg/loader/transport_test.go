@@ -0,0 +1,125 @@
+package loader
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/generator"
+)
+
+func TestHTTPSenderTransport(t *testing.T) {
+	tests := []struct {
+		name      string
+		transport func(tc *HTTPTransportConfig)
+		tls       bool
+		http2     bool
+		workers   int
+
+		wantProto  int
+		wantNew    int64
+		wantReused int64
+	}{
+		{name: "keep-alive", transport: func(*HTTPTransportConfig) {}, workers: 1, wantProto: 1, wantNew: 1, wantReused: 9},
+		{name: "keep-alive disabled", transport: func(tc *HTTPTransportConfig) { tc.DisableKeepAlives = true }, workers: 1, wantProto: 1, wantNew: 10},
+		{name: "connection per worker", transport: func(tc *HTTPTransportConfig) { tc.ConnectionPerWorker = true }, workers: 5, wantProto: 1, wantNew: 5, wantReused: 5},
+		{name: "h2c", transport: func(tc *HTTPTransportConfig) { tc.HTTP2 = true }, http2: true, workers: 1, wantProto: 2, wantNew: 1, wantReused: 9},
+		{name: "http2 over tls", transport: func(tc *HTTPTransportConfig) { tc.HTTP2 = true }, tls: true, http2: true, workers: 1, wantProto: 2, wantNew: 1, wantReused: 9},
+		{name: "http1 over tls", transport: func(*HTTPTransportConfig) {}, tls: true, http2: true, workers: 1, wantProto: 1, wantNew: 1, wantReused: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var proto atomic.Int64
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				proto.Store(int64(r.ProtoMajor))
+			}))
+			if tt.http2 {
+				server.EnableHTTP2 = true
+				server.Config.Protocols = new(http.Protocols)
+				server.Config.Protocols.SetHTTP1(true)
+				server.Config.Protocols.SetHTTP2(true)
+				server.Config.Protocols.SetUnencryptedHTTP2(true)
+			}
+			if tt.tls {
+				server.StartTLS()
+			} else {
+				server.Start()
+			}
+			defer server.Close()
+
+			cfg := HTTPConfig{}.defaults()
+			cfg.Host = "127.0.0.1"
+			cfg.Port = server.Listener.Addr().(*net.TCPAddr).Port
+			cfg.URI = "/api/load"
+			cfg.Method = http.MethodPost
+			if tt.tls {
+				cfg.Scheme = "https"
+				cfg.TLS = &TLSConfig{InsecureSkipVerify: true}
+			}
+			tt.transport(cfg.Transport)
+			if err := cfg.validate(); err != nil {
+				t.Fatalf("invalid config: %v", err)
+			}
+
+			sender, err := newHTTPSender(cfg, &rawEncoder{})
+			if err != nil {
+				t.Fatalf("failed to create the sender: %v", err)
+			}
+			defer sender.close()
+
+			// The requests are sent one by one, so the idle connection is always reused if the keep-alive is enabled.
+			total := &sendResult{}
+			for i := 0; i < 10; i++ {
+				result, err := sender.send(context.Background(), &worker{id: i % tt.workers}, &generator.GeneratorOutput{Data: []byte("test")})
+				if err != nil {
+					t.Fatalf("failed to send: %v", err)
+				}
+				total.add(result)
+			}
+
+			if proto.Load() != int64(tt.wantProto) {
+				t.Fatalf("unexpected protocol: 'HTTP/%d', expected: 'HTTP/%d'", proto.Load(), tt.wantProto)
+			}
+
+			if total.newConnections != tt.wantNew || total.reusedConnections != tt.wantReused {
+				t.Fatalf("unexpected new connections: '%d' and reused connections: '%d', expected: '%d' and '%d'",
+					total.newConnections, total.reusedConnections, tt.wantNew, tt.wantReused)
+			}
+		})
+	}
+}
+
+func TestHTTPSenderTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+
+		// The response header is received in time but the body is not.
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	cfg := HTTPConfig{}.defaults()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = server.Listener.Addr().(*net.TCPAddr).Port
+	cfg.URI = "/api/load"
+	cfg.Method = http.MethodPost
+	cfg.Transport.Timeout = 100 * time.Millisecond
+
+	sender, err := newHTTPSender(cfg, &rawEncoder{})
+	if err != nil {
+		t.Fatalf("failed to create the sender: %v", err)
+	}
+	defer sender.close()
+
+	_, err = sender.send(context.Background(), &worker{}, &generator.GeneratorOutput{Data: []byte("test")})
+	if err == nil || !strings.Contains(err.Error(), "Client.Timeout") {
+		t.Fatalf("unexpected error: '%v', expected the timeout of the client", err)
+	}
+}
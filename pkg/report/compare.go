@@ -0,0 +1,173 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"text/tabwriter"
+)
+
+// Thresholds are the max changes in percent that are not regressions.
+type Thresholds struct {
+	// Throughput is the max drop of the rates and the throughputs in percent.
+	Throughput float64
+
+	// Latency is the max increase of the latency percentiles in percent.
+	Latency float64
+}
+
+// Comparison is the comparison of the runs with the baseline, which is the first run.
+type Comparison struct {
+	// Names are the names of the runs, for example, the paths of the reports.
+	Names []string
+
+	Rows []*ComparisonRow
+}
+
+// ComparisonRow is the comparison of a metric.
+type ComparisonRow struct {
+	Metric string
+
+	// Values are the values of the runs. The first one is the baseline.
+	Values []float64
+
+	// Deltas are the changes of the runs compared with the baseline in percent. The first one is always 0.
+	Deltas []float64
+
+	// Regressions tell whether the changes of the runs are regressions.
+	Regressions []bool
+}
+
+// comparedMetric is a metric of the summary to compare.
+type comparedMetric struct {
+	name string
+
+	// higherIsBetter is true for the throughputs and false for the latencies.
+	higherIsBetter bool
+
+	value func(s *Summary) float64
+}
+
+var comparedMetrics = []*comparedMetric{
+	{name: "requests/s", higherIsBetter: true, value: func(s *Summary) float64 { return s.Rate }},
+	{name: "records/s", higherIsBetter: true, value: func(s *Summary) float64 { return s.RecordsRate }},
+	{name: "raw MB/s", higherIsBetter: true, value: func(s *Summary) float64 { return s.RawMBPerSecond }},
+	{name: "wire MB/s", higherIsBetter: true, value: func(s *Summary) float64 { return s.WireMBPerSecond }},
+	{name: "latency p50 (ms)", value: func(s *Summary) float64 { return s.Latency.P50 }},
+	{name: "latency p90 (ms)", value: func(s *Summary) float64 { return s.Latency.P90 }},
+	{name: "latency p99 (ms)", value: func(s *Summary) float64 { return s.Latency.P99 }},
+	{name: "latency p99.9 (ms)", value: func(s *Summary) float64 { return s.Latency.P999 }},
+	{name: "latency max (ms)", value: func(s *Summary) float64 { return s.Latency.Max }},
+}
+
+// Load loads the JSON report from the file.
+func Load(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse the report '%s': %w", path, err)
+	}
+
+	if r.Summary == nil || r.Summary.Latency == nil {
+		return nil, fmt.Errorf("the report '%s' has no summary", path)
+	}
+
+	return &r, nil
+}
+
+// Compare compares the runs with the baseline, which is the first run, and flags the changes beyond the thresholds as regressions.
+func Compare(names []string, reports []*Report, thresholds *Thresholds) (*Comparison, error) {
+	if len(reports) < 2 {
+		return nil, fmt.Errorf("at least two reports are required to compare")
+	}
+
+	if len(names) != len(reports) {
+		return nil, fmt.Errorf("the number of the names '%d' doesn't match the number of the reports '%d'", len(names), len(reports))
+	}
+
+	c := &Comparison{Names: names}
+	for _, metric := range comparedMetrics {
+		row := &ComparisonRow{Metric: metric.name}
+		base := metric.value(reports[0].Summary)
+		for _, r := range reports {
+			value := metric.value(r.Summary)
+			delta := deltaPercent(base, value)
+
+			var regression bool
+			if metric.higherIsBetter {
+				regression = -delta > thresholds.Throughput
+			} else {
+				regression = delta > thresholds.Latency
+			}
+
+			row.Values = append(row.Values, value)
+			row.Deltas = append(row.Deltas, delta)
+			row.Regressions = append(row.Regressions, regression)
+		}
+		c.Rows = append(c.Rows, row)
+	}
+
+	return c, nil
+}
+
+// Regressions returns the number of the regressions.
+func (c *Comparison) Regressions() int {
+	var count int
+	for _, row := range c.Rows {
+		for _, regression := range row.Regressions {
+			if regression {
+				count++
+			}
+		}
+	}
+
+	return count
+}
+
+// Print prints the side-by-side comparison table. The regressions are marked with `!`.
+func (c *Comparison) Print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprint(tw, "METRIC")
+	for _, name := range c.Names {
+		fmt.Fprintf(tw, "\t%s", name)
+	}
+	fmt.Fprintln(tw)
+
+	for _, row := range c.Rows {
+		fmt.Fprint(tw, row.Metric)
+		for i, value := range row.Values {
+			if i == 0 {
+				fmt.Fprintf(tw, "\t%.3f", value)
+				continue
+			}
+
+			mark := ""
+			if row.Regressions[i] {
+				mark = " !"
+			}
+			fmt.Fprintf(tw, "\t%.3f (%+.2f%%)%s", value, row.Deltas[i], mark)
+		}
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+// deltaPercent returns the change from the base in percent. It's 0 if both are 0 and infinity if only the base is 0.
+func deltaPercent(base, value float64) float64 {
+	if base == 0 {
+		if value == 0 {
+			return 0
+		}
+		return math.Inf(int(math.Copysign(1, value)))
+	}
+
+	return (value - base) / math.Abs(base) * 100
+}
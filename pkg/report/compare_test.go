@@ -0,0 +1,89 @@
+package report
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newTestReport(rate, p99 float64) *Report {
+	return &Report{
+		Summary: &Summary{
+			Rate:        rate,
+			RecordsRate: rate * 10,
+			Latency:     &Latency{P50: 1, P90: 2, P99: p99, P999: p99, Max: p99},
+			SendLag:     &Latency{},
+		},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		rate        float64
+		p99         float64
+		regressions int
+	}{
+		{name: "same", rate: 100, p99: 10},
+		{name: "within thresholds", rate: 96, p99: 10.9},
+		{name: "faster", rate: 200, p99: 5},
+		{name: "throughput drop", rate: 90, p99: 10, regressions: 2},
+		{name: "latency increase", rate: 100, p99: 12, regressions: 3},
+	}
+
+	thresholds := &Thresholds{Throughput: 5, Latency: 10}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comparison, err := Compare([]string{"base", "new"}, []*Report{newTestReport(100, 10), newTestReport(tt.rate, tt.p99)}, thresholds)
+			if err != nil {
+				t.Fatalf("failed to compare: %v", err)
+			}
+
+			if regressions := comparison.Regressions(); regressions != tt.regressions {
+				t.Fatalf("unexpected regressions: '%d', expected: '%d'", regressions, tt.regressions)
+			}
+		})
+	}
+}
+
+func TestComparePrint(t *testing.T) {
+	dir := t.TempDir()
+
+	var paths []string
+	for i, r := range []*Report{newTestReport(100, 10), newTestReport(80, 10)} {
+		path := filepath.Join(dir, []string{"base.json", "new.json"}[i])
+		if err := r.Write(path); err != nil {
+			t.Fatalf("failed to write the report: %v", err)
+		}
+		paths = append(paths, path)
+	}
+
+	var reports []*Report
+	for _, path := range paths {
+		r, err := Load(path)
+		if err != nil {
+			t.Fatalf("failed to load the report: %v", err)
+		}
+		reports = append(reports, r)
+	}
+
+	comparison, err := Compare(paths, reports, &Thresholds{Throughput: 5, Latency: 10})
+	if err != nil {
+		t.Fatalf("failed to compare: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := comparison.Print(&buf); err != nil {
+		t.Fatalf("failed to print: %v", err)
+	}
+
+	if !strings.Contains(buf.String(), "80.000 (-20.00%) !") {
+		t.Fatalf("the regression is not marked:\n%s", buf.String())
+	}
+
+	if _, err := Load(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Fatalf("unexpected error of the missing report: %v", err)
+	}
+}
@@ -0,0 +1,395 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/config"
+)
+
+const (
+	// FormatJSON is the format of the full report document.
+	FormatJSON = "json"
+
+	// FormatCSV is the format of the per-interval time series of the report.
+	FormatCSV = "csv"
+)
+
+// Report is the machine-readable result of a benchmark run, which can be archived and compared with the other runs.
+type Report struct {
+	// Type is the type of the benchmark, for example, `logs`.
+	Type string `json:"type"`
+
+	Start time.Time `json:"start"`
+	Stop  time.Time `json:"stop"`
+
+	// Config is the effective config of the run, which has the defaults applied.
+	Config map[string]any `json:"config"`
+
+	Environment *Environment `json:"environment"`
+
+	// Summary is the totals and the rates of the whole run.
+	Summary *Summary `json:"summary"`
+
+	// Stages are the summaries of the stages of the load profile.
+	Stages []*Summary `json:"stages,omitempty"`
+
+	Failures []*Failure `json:"failures,omitempty"`
+
+	// LatencyHistogram is the distribution of the latency of the whole run.
+	LatencyHistogram []*Bucket `json:"latency_histogram,omitempty"`
+
+	// Intervals are the per-interval time series of the run.
+	Intervals []*Interval `json:"intervals"`
+}
+
+// Environment is the information of the machine that runs the benchmark.
+type Environment struct {
+	Hostname  string `json:"hostname"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	GoVersion string `json:"go_version"`
+}
+
+// Summary is the totals and the rates of the run or a stage.
+type Summary struct {
+	// Name is the name of the stage. It's empty for the whole run.
+	Name string `json:"name,omitempty"`
+
+	// DurationSeconds is the measured duration of the run or the stage, and the rates are computed from it.
+	// It's 0 for the stage that is never reached, for example, when the run is interrupted.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	Success         int64 `json:"success"`
+	Failure         int64 `json:"failure"`
+	Records         int64 `json:"records"`
+	RejectedRecords int64 `json:"rejected_records"`
+	RawBytes        int64 `json:"raw_bytes"`
+	WireBytes       int64 `json:"wire_bytes"`
+
+	// FirstAttemptSuccess and RetriedSuccess split the successful requests by whether they are retried.
+	// ExhaustedRetries is the number of the failed requests that are retried until the max attempts,
+	// and AbandonedRetries is the number of the failed requests whose retries are given up because the test is stopping.
+	FirstAttemptSuccess int64 `json:"first_attempt_success"`
+	RetriedSuccess      int64 `json:"retried_success"`
+	Retries             int64 `json:"retries"`
+	ExhaustedRetries    int64 `json:"exhausted_retries"`
+	AbandonedRetries    int64 `json:"abandoned_retries"`
+
+	// NewConnections and ReusedConnections are the number of the requests sent by the new and the reused connections.
+	NewConnections    int64 `json:"new_connections"`
+	ReusedConnections int64 `json:"reused_connections"`
+
+	Rate             float64 `json:"rate"`
+	RecordsRate      float64 `json:"records_rate"`
+	RawMBPerSecond   float64 `json:"raw_mb_per_second"`
+	WireMBPerSecond  float64 `json:"wire_mb_per_second"`
+	CompressionRatio float64 `json:"compression_ratio"`
+
+	// CompressionCPUSeconds is the CPU time spent on the compression of the requests.
+	CompressionCPUSeconds float64 `json:"compression_cpu_seconds"`
+
+	// GenerateSeconds and SendSeconds are the time spent on generating the payloads and sending the requests.
+	GenerateSeconds float64 `json:"generate_seconds"`
+	SendSeconds     float64 `json:"send_seconds"`
+
+	// PoolSize and PoolPrepareSeconds are the size of the payload pool and the time spent on preparing it. They are only set for the whole run.
+	PoolSize           int     `json:"pool_size,omitempty"`
+	PoolPrepareSeconds float64 `json:"pool_prepare_seconds,omitempty"`
+
+	Latency *Latency `json:"latency"`
+	SendLag *Latency `json:"send_lag"`
+}
+
+// Latency is the percentiles of a latency histogram in milliseconds.
+type Latency struct {
+	Min   float64 `json:"min_ms"`
+	Mean  float64 `json:"mean_ms"`
+	P50   float64 `json:"p50_ms"`
+	P90   float64 `json:"p90_ms"`
+	P99   float64 `json:"p99_ms"`
+	P999  float64 `json:"p99_9_ms"`
+	Max   float64 `json:"max_ms"`
+	Count int64   `json:"count"`
+}
+
+// Failure is the number of the failures of a failure class.
+type Failure struct {
+	Class   string   `json:"class"`
+	Count   int64    `json:"count"`
+	Samples []string `json:"samples"`
+}
+
+// Bucket is a bucket of the latency histogram. It has the requests with the latency in (the upper bound of the previous bucket, the upper bound].
+type Bucket struct {
+	UpperBound float64 `json:"upper_bound_ms"`
+	Count      int64   `json:"count"`
+}
+
+// Interval is the stats of a sample interval.
+type Interval struct {
+	Start           time.Time `json:"start"`
+	ElapsedSeconds  float64   `json:"elapsed_seconds"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Success         int64     `json:"success"`
+	Failure         int64     `json:"failure"`
+	Records         int64     `json:"records"`
+	RawBytes        int64     `json:"raw_bytes"`
+	WireBytes       int64     `json:"wire_bytes"`
+	Rate            float64   `json:"rate"`
+	TargetRate      float64   `json:"target_rate"`
+	LatencyP50      float64   `json:"latency_p50_ms"`
+	LatencyP99      float64   `json:"latency_p99_ms"`
+	LatencyMax      float64   `json:"latency_max_ms"`
+}
+
+// New creates the report of the run from the stopped collector.
+func New(typ string, cfg *config.Config, c *collector.Collector) (*Report, error) {
+	cfgMap, err := cfg.Map()
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	r := &Report{
+		Type:   typ,
+		Start:  c.StartTime(),
+		Stop:   c.StopTime(),
+		Config: cfgMap,
+		Environment: &Environment{
+			Hostname:  hostname,
+			OS:        runtime.GOOS,
+			Arch:      runtime.GOARCH,
+			CPUs:      runtime.NumCPU(),
+			GoVersion: runtime.Version(),
+		},
+		Summary: newSummary(c),
+	}
+
+	for _, stage := range c.Stages() {
+		r.Stages = append(r.Stages, newSummary(stage))
+	}
+
+	for _, failure := range c.Failures() {
+		r.Failures = append(r.Failures, &Failure{Class: failure.Class, Count: failure.Count, Samples: failure.Samples})
+	}
+
+	r.LatencyHistogram = newBuckets(c.Latency())
+
+	for _, interval := range c.Intervals() {
+		r.Intervals = append(r.Intervals, &Interval{
+			Start:           interval.Start,
+			ElapsedSeconds:  interval.Start.Sub(r.Start).Seconds(),
+			DurationSeconds: interval.Duration.Seconds(),
+			Success:         interval.Success,
+			Failure:         interval.Failure,
+			Records:         interval.Records,
+			RawBytes:        interval.RawBytes,
+			WireBytes:       interval.WireBytes,
+			Rate:            interval.Rate(),
+			TargetRate:      interval.TargetRate,
+			LatencyP50:      milliseconds(interval.LatencyP50),
+			LatencyP99:      milliseconds(interval.LatencyP99),
+			LatencyMax:      milliseconds(interval.LatencyMax),
+		})
+	}
+
+	return r, nil
+}
+
+// Write writes the report into the file. The format is decided by the extension of the file, and it's JSON by default.
+func (r *Report) Write(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if FormatOf(path) == FormatCSV {
+		err = r.WriteCSV(f)
+	} else {
+		err = r.WriteJSON(f)
+	}
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write the report '%s': %w", path, err)
+	}
+
+	return f.Close()
+}
+
+// WriteJSON writes the full report document in JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// csvHeader is the header of the CSV report.
+var csvHeader = []string{
+	"start", "elapsed_seconds", "duration_seconds", "success", "failure", "records", "raw_bytes", "wire_bytes",
+	"rate", "target_rate", "latency_p50_ms", "latency_p99_ms", "latency_max_ms",
+}
+
+// WriteCSV writes the per-interval time series in CSV, one row for each interval.
+// The config and the environment are only in the JSON report because they are not tabular.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, interval := range r.Intervals {
+		row := []string{
+			interval.Start.Format(time.RFC3339Nano),
+			formatFloat(interval.ElapsedSeconds),
+			formatFloat(interval.DurationSeconds),
+			strconv.FormatInt(interval.Success, 10),
+			strconv.FormatInt(interval.Failure, 10),
+			strconv.FormatInt(interval.Records, 10),
+			strconv.FormatInt(interval.RawBytes, 10),
+			strconv.FormatInt(interval.WireBytes, 10),
+			formatFloat(interval.Rate),
+			formatFloat(interval.TargetRate),
+			formatFloat(interval.LatencyP50),
+			formatFloat(interval.LatencyP99),
+			formatFloat(interval.LatencyMax),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// FormatOf returns the format of the report file by its extension.
+func FormatOf(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return FormatCSV
+	}
+
+	return FormatJSON
+}
+
+func newSummary(c *collector.Collector) *Summary {
+	return &Summary{
+		Name:                  c.Name(),
+		DurationSeconds:       c.Duration().Seconds(),
+		Success:               c.SuccessCount(),
+		Failure:               c.FailureCount(),
+		Records:               c.RecordsCount(),
+		RejectedRecords:       c.RejectedRecordsCount(),
+		RawBytes:              c.RawBytes(),
+		WireBytes:             c.WireBytes(),
+		FirstAttemptSuccess:   c.FirstAttemptSuccessCount(),
+		RetriedSuccess:        c.RetriedSuccessCount(),
+		Retries:               c.RetriesCount(),
+		ExhaustedRetries:      c.ExhaustedRetriesCount(),
+		AbandonedRetries:      c.AbandonedRetriesCount(),
+		NewConnections:        c.NewConnectionsCount(),
+		ReusedConnections:     c.ReusedConnectionsCount(),
+		Rate:                  finite(c.Rate()),
+		RecordsRate:           finite(c.RecordsRate()),
+		RawMBPerSecond:        finite(c.RawThroughput()),
+		WireMBPerSecond:       finite(c.WireThroughput()),
+		CompressionRatio:      c.CompressionRatio(),
+		CompressionCPUSeconds: c.CompressionTime().Seconds(),
+		GenerateSeconds:       c.GenerateTime().Seconds(),
+		SendSeconds:           c.SendTime().Seconds(),
+		PoolSize:              c.PoolSize(),
+		PoolPrepareSeconds:    c.PoolPrepareTime().Seconds(),
+		Latency:               newLatency(c.Latency()),
+		SendLag:               newLatency(c.SendLag()),
+	}
+}
+
+func newLatency(h *collector.Histogram) *Latency {
+	return &Latency{
+		Min:   milliseconds(h.Min()),
+		Mean:  milliseconds(h.Mean()),
+		P50:   milliseconds(h.Percentile(50)),
+		P90:   milliseconds(h.Percentile(90)),
+		P99:   milliseconds(h.Percentile(99)),
+		P999:  milliseconds(h.Percentile(99.9)),
+		Max:   milliseconds(h.Max()),
+		Count: h.Count(),
+	}
+}
+
+// newBuckets returns the buckets of the histogram. The upper bounds are 1, 2 or 5 times a power of 10 in milliseconds,
+// from the first one that covers the min latency to the first one that covers the max latency.
+func newBuckets(h *collector.Histogram) []*Bucket {
+	if h.Count() == 0 {
+		return nil
+	}
+
+	var (
+		buckets []*Bucket
+		below   int64
+		bound   = time.Microsecond
+		steps   = []int64{1, 2, 5}
+		step    int
+	)
+	for bound < h.Min() {
+		step, bound = nextBound(steps, step, bound)
+	}
+
+	for {
+		if bound >= h.Max() {
+			// The last bucket has all the rest, so the counts add up to the total even if the bound falls into a bucket of the histogram.
+			buckets = append(buckets, &Bucket{UpperBound: milliseconds(bound), Count: h.Count() - below})
+			return buckets
+		}
+
+		count := h.CountAtOrBelow(bound)
+		buckets = append(buckets, &Bucket{UpperBound: milliseconds(bound), Count: count - below})
+		below = count
+		step, bound = nextBound(steps, step, bound)
+	}
+}
+
+// nextBound returns the next bound of the 1-2-5 series.
+func nextBound(steps []int64, step int, bound time.Duration) (int, time.Duration) {
+	next := (step + 1) % len(steps)
+	if next == 0 {
+		return next, bound * 10 / time.Duration(steps[step])
+	}
+
+	return next, bound / time.Duration(steps[step]) * time.Duration(steps[next])
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// finite returns 0 for NaN and infinity, which can't be encoded in JSON, for example, the rate of a run with zero duration.
+func finite(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0
+	}
+
+	return v
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,134 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+	"github.com/zyy17/o11ybench/pkg/config"
+)
+
+func TestReport(t *testing.T) {
+	cfg, err := config.New("../config/testdata/config.yaml")
+	if err != nil {
+		t.Fatalf("failed to create config: %v", err)
+	}
+
+	c := collector.New()
+	c.SetSampleInterval(100 * time.Millisecond)
+	warmup := c.AddStage("warmup")
+	c.AddStage("peak")
+	c.Start()
+	warmup.Start()
+	for i := 0; i < 3; i++ {
+		warmup.IncSuccessCount(10)
+		c.IncRecordsCount(100)
+		c.ObserveLatency(time.Duration(i+1) * time.Millisecond)
+		time.Sleep(100 * time.Millisecond)
+	}
+	warmup.Stop()
+	c.RecordFailure(errors.New("boom"))
+	c.Stop()
+
+	r, err := New("logs", cfg, c)
+	if err != nil {
+		t.Fatalf("failed to create report: %v", err)
+	}
+
+	dir := t.TempDir()
+	jsonPath, csvPath := filepath.Join(dir, "result.json"), filepath.Join(dir, "nested", "result.csv")
+	for _, path := range []string{jsonPath, csvPath} {
+		if err := r.Write(path); err != nil {
+			t.Fatalf("failed to write the report: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(jsonPath)
+	if err != nil {
+		t.Fatalf("failed to read the report: %v", err)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to decode the report: %v", err)
+	}
+
+	if decoded.Type != "logs" || decoded.Summary.Success != 30 || decoded.Summary.Failure != 1 || decoded.Summary.Records != 300 {
+		t.Fatalf("unexpected summary: %+v", decoded.Summary)
+	}
+
+	if decoded.Summary.Latency.Count != 3 || decoded.Summary.Latency.Max != 3 {
+		t.Fatalf("unexpected latency: %+v", decoded.Summary.Latency)
+	}
+
+	if _, ok := decoded.Config["loader"]; !ok {
+		t.Fatalf("the effective config is not in the report: %v", decoded.Config)
+	}
+
+	// The stages are summarized with their measured durations, and the stage that is never reached has no duration and no rate.
+	if len(decoded.Stages) != 2 {
+		t.Fatalf("unexpected stages: %+v", decoded.Stages)
+	}
+
+	if stage := decoded.Stages[0]; stage.Name != "warmup" || stage.DurationSeconds != warmup.Duration().Seconds() || stage.Rate != 30/warmup.Duration().Seconds() {
+		t.Fatalf("unexpected stage: %+v, measured duration: '%s'", stage, warmup.Duration())
+	}
+
+	if stage := decoded.Stages[1]; stage.Name != "peak" || stage.DurationSeconds != 0 || stage.Rate != 0 {
+		t.Fatalf("unexpected stage: %+v", stage)
+	}
+
+	if decoded.Environment == nil || decoded.Environment.CPUs == 0 {
+		t.Fatalf("unexpected environment: %+v", decoded.Environment)
+	}
+
+	if len(decoded.Failures) != 1 || decoded.Failures[0].Class != collector.FailureClassOther {
+		t.Fatalf("unexpected failures: %+v", decoded.Failures)
+	}
+
+	var success int64
+	for _, interval := range decoded.Intervals {
+		success += interval.Success
+	}
+	if len(decoded.Intervals) < 3 || success != 30 {
+		t.Fatalf("unexpected intervals: '%d', success: '%d'", len(decoded.Intervals), success)
+	}
+
+	data, err = os.ReadFile(csvPath)
+	if err != nil {
+		t.Fatalf("failed to read the report: %v", err)
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to decode the CSV report: %v", err)
+	}
+
+	if len(rows) != len(decoded.Intervals)+1 || rows[0][0] != "start" {
+		t.Fatalf("unexpected CSV rows: %v", rows)
+	}
+}
+
+func TestFormatOf(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{path: "result.json", expected: FormatJSON},
+		{path: "result.csv", expected: FormatCSV},
+		{path: "result.CSV", expected: FormatCSV},
+		{path: "result", expected: FormatJSON},
+	}
+
+	for _, tt := range tests {
+		if actual := FormatOf(tt.path); actual != tt.expected {
+			t.Fatalf("FormatOf('%s'): '%s', expected: '%s'", tt.path, actual, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,264 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	chartWidth  = 800
+	chartHeight = 400
+
+	chartMarginLeft   = 70
+	chartMarginRight  = 30
+	chartMarginTop    = 50
+	chartMarginBottom = 60
+
+	// chartTicks is the approximate number of the ticks on the axes.
+	chartTicks = 5
+)
+
+// chartColors are the colors of the series in a chart.
+var chartColors = []string{"#1f77b4", "#ff7f0e", "#d62728", "#2ca02c", "#9467bd"}
+
+// chartSeries is a line in the chart.
+type chartSeries struct {
+	name   string
+	points [][2]float64
+}
+
+// lineChart is a chart of the series over time.
+type lineChart struct {
+	title  string
+	xLabel string
+	yLabel string
+	series []*chartSeries
+}
+
+// barChart is a chart of the counts in the labeled buckets.
+type barChart struct {
+	title  string
+	xLabel string
+	yLabel string
+	labels []string
+	values []float64
+}
+
+// WriteCharts writes the self-contained SVG charts of the report into the directory:
+//   - `throughput.svg`: the actual and the target rate over time.
+//   - `latency.svg`: the latency percentiles over time.
+//   - `errors.svg`: the error rate over time.
+//   - `latency_histogram.svg`: the distribution of the latency.
+func (r *Report) WriteCharts(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	charts := map[string][]byte{
+		"throughput.svg":        r.throughputChart().svg(),
+		"latency.svg":           r.latencyChart().svg(),
+		"errors.svg":            r.errorsChart().svg(),
+		"latency_histogram.svg": r.latencyHistogramChart().svg(),
+	}
+
+	for name, data := range charts {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *Report) throughputChart() *lineChart {
+	actual, target := &chartSeries{name: "actual"}, &chartSeries{name: "target"}
+	for _, interval := range r.Intervals {
+		x := interval.ElapsedSeconds + interval.DurationSeconds
+		actual.points = append(actual.points, [2]float64{x, interval.Rate})
+		target.points = append(target.points, [2]float64{x, interval.TargetRate})
+	}
+
+	return &lineChart{title: "Throughput", xLabel: "elapsed (s)", yLabel: "requests/s", series: []*chartSeries{actual, target}}
+}
+
+func (r *Report) latencyChart() *lineChart {
+	p50, p99, maxLatency := &chartSeries{name: "p50"}, &chartSeries{name: "p99"}, &chartSeries{name: "max"}
+	for _, interval := range r.Intervals {
+		x := interval.ElapsedSeconds + interval.DurationSeconds
+		p50.points = append(p50.points, [2]float64{x, interval.LatencyP50})
+		p99.points = append(p99.points, [2]float64{x, interval.LatencyP99})
+		maxLatency.points = append(maxLatency.points, [2]float64{x, interval.LatencyMax})
+	}
+
+	return &lineChart{title: "Latency", xLabel: "elapsed (s)", yLabel: "latency (ms)", series: []*chartSeries{p50, p99, maxLatency}}
+}
+
+func (r *Report) errorsChart() *lineChart {
+	errorRate := &chartSeries{name: "error rate"}
+	for _, interval := range r.Intervals {
+		var rate float64
+		if total := interval.Success + interval.Failure; total > 0 {
+			rate = float64(interval.Failure) / float64(total) * 100
+		}
+		errorRate.points = append(errorRate.points, [2]float64{interval.ElapsedSeconds + interval.DurationSeconds, rate})
+	}
+
+	return &lineChart{title: "Error rate", xLabel: "elapsed (s)", yLabel: "failed requests (%)", series: []*chartSeries{errorRate}}
+}
+
+func (r *Report) latencyHistogramChart() *barChart {
+	chart := &barChart{title: "Latency histogram", xLabel: "latency (ms, upper bound)", yLabel: "requests"}
+	for _, bucket := range r.LatencyHistogram {
+		chart.labels = append(chart.labels, formatTick(bucket.UpperBound))
+		chart.values = append(chart.values, float64(bucket.Count))
+	}
+
+	return chart
+}
+
+func (c *lineChart) svg() []byte {
+	var maxX, maxY float64
+	for _, s := range c.series {
+		for _, p := range s.points {
+			maxX, maxY = max(maxX, p[0]), max(maxY, p[1])
+		}
+	}
+	xTicks, yTicks := niceTicks(maxX), niceTicks(maxY)
+	maxX, maxY = xTicks[len(xTicks)-1], yTicks[len(yTicks)-1]
+
+	var buf bytes.Buffer
+	writeChartFrame(&buf, c.title, c.xLabel, c.yLabel)
+
+	for _, tick := range xTicks {
+		x := scaleX(tick, maxX)
+		fmt.Fprintf(&buf, `<line x1="%.1f" y1="%d" x2="%.1f" y2="%d" stroke="#000"/>`+"\n", x, chartHeight-chartMarginBottom, x, chartHeight-chartMarginBottom+5)
+		fmt.Fprintf(&buf, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x, chartHeight-chartMarginBottom+20, formatTick(tick))
+	}
+	writeYTicks(&buf, yTicks, maxY)
+
+	for i, s := range c.series {
+		if len(s.points) == 0 {
+			continue
+		}
+
+		color := chartColors[i%len(chartColors)]
+		buf.WriteString(`<polyline fill="none" stroke-width="2" stroke="` + color + `" points="`)
+		for j, p := range s.points {
+			if j > 0 {
+				buf.WriteByte(' ')
+			}
+			fmt.Fprintf(&buf, "%.1f,%.1f", scaleX(p[0], maxX), scaleY(p[1], maxY))
+		}
+		buf.WriteString(`"/>` + "\n")
+
+		// The legend is at the top right corner of the plot.
+		y := chartMarginTop + 10 + i*18
+		fmt.Fprintf(&buf, `<rect x="%d" y="%d" width="12" height="4" fill="%s"/>`+"\n", chartWidth-chartMarginRight-110, y-4, color)
+		fmt.Fprintf(&buf, `<text x="%d" y="%d">%s</text>`+"\n", chartWidth-chartMarginRight-92, y+1, html.EscapeString(s.name))
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+func (c *barChart) svg() []byte {
+	var maxY float64
+	for _, v := range c.values {
+		maxY = max(maxY, v)
+	}
+	yTicks := niceTicks(maxY)
+	maxY = yTicks[len(yTicks)-1]
+
+	var buf bytes.Buffer
+	writeChartFrame(&buf, c.title, c.xLabel, c.yLabel)
+	writeYTicks(&buf, yTicks, maxY)
+
+	if len(c.values) > 0 {
+		width := float64(chartWidth-chartMarginLeft-chartMarginRight) / float64(len(c.values))
+		for i, v := range c.values {
+			x := chartMarginLeft + float64(i)*width
+			y := scaleY(v, maxY)
+			fmt.Fprintf(&buf, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", x+width*0.1, y, width*0.8, float64(chartHeight-chartMarginBottom)-y, chartColors[0])
+			fmt.Fprintf(&buf, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x+width/2, chartHeight-chartMarginBottom+20, html.EscapeString(c.labels[i]))
+		}
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// writeChartFrame writes the header of the SVG, the title, the axes and their labels.
+func writeChartFrame(buf *bytes.Buffer, title, xLabel, yLabel string) {
+	fmt.Fprintf(buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n", chartWidth, chartHeight, chartWidth, chartHeight)
+	fmt.Fprintf(buf, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", chartWidth, chartHeight)
+	fmt.Fprintf(buf, `<text x="%d" y="%d" text-anchor="middle" font-size="16" font-weight="bold">%s</text>`+"\n", chartWidth/2, chartMarginTop/2+5, html.EscapeString(title))
+
+	// The axes.
+	fmt.Fprintf(buf, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#000"/>`+"\n", chartMarginLeft, chartHeight-chartMarginBottom, chartWidth-chartMarginRight, chartHeight-chartMarginBottom)
+	fmt.Fprintf(buf, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#000"/>`+"\n", chartMarginLeft, chartMarginTop, chartMarginLeft, chartHeight-chartMarginBottom)
+
+	fmt.Fprintf(buf, `<text x="%d" y="%d" text-anchor="middle">%s</text>`+"\n", (chartWidth+chartMarginLeft-chartMarginRight)/2, chartHeight-15, html.EscapeString(xLabel))
+	fmt.Fprintf(buf, `<text x="%d" y="%d" text-anchor="middle" transform="rotate(-90 %d %d)">%s</text>`+"\n", 15, (chartHeight+chartMarginTop-chartMarginBottom)/2, 15, (chartHeight+chartMarginTop-chartMarginBottom)/2, html.EscapeString(yLabel))
+}
+
+// writeYTicks writes the ticks and the grid lines of the y axis.
+func writeYTicks(buf *bytes.Buffer, ticks []float64, maxY float64) {
+	for _, tick := range ticks {
+		y := scaleY(tick, maxY)
+		fmt.Fprintf(buf, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", chartMarginLeft, y, chartWidth-chartMarginRight, y)
+		fmt.Fprintf(buf, `<text x="%d" y="%.1f" text-anchor="end">%s</text>`+"\n", chartMarginLeft-8, y+4, formatTick(tick))
+	}
+}
+
+func scaleX(v, maxX float64) float64 {
+	return chartMarginLeft + v/maxX*float64(chartWidth-chartMarginLeft-chartMarginRight)
+}
+
+func scaleY(v, maxY float64) float64 {
+	return float64(chartHeight-chartMarginBottom) - v/maxY*float64(chartHeight-chartMarginTop-chartMarginBottom)
+}
+
+// niceTicks returns the ticks from 0 to the value that is not less than v, and the step is 1, 2 or 5 times a power of 10.
+func niceTicks(v float64) []float64 {
+	if v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return []float64{0, 1}
+	}
+
+	var (
+		raw = v / chartTicks
+		exp = math.Floor(math.Log10(raw))
+
+		// mult is the step in the unit of the power of 10.
+		mult = 10.0
+	)
+	for _, m := range []float64{1, 2, 5} {
+		if m*math.Pow(10, exp) >= raw {
+			mult = m
+			break
+		}
+	}
+
+	ticks := []float64{0}
+	for n := 1.0; ; n++ {
+		// The negative power is applied by the division, so the ticks like `0.3` are exact instead of `0.30000000000000004`.
+		tick := n * mult * math.Pow(10, exp)
+		if exp < 0 {
+			tick = n * mult / math.Pow(10, -exp)
+		}
+		ticks = append(ticks, tick)
+		if tick >= v {
+			break
+		}
+	}
+
+	return ticks
+}
+
+func formatTick(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
@@ -0,0 +1,103 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/zyy17/o11ybench/pkg/collector"
+)
+
+func TestWriteCharts(t *testing.T) {
+	r := &Report{
+		LatencyHistogram: []*Bucket{{UpperBound: 1, Count: 10}, {UpperBound: 2, Count: 5}},
+	}
+	for i := 0; i < 5; i++ {
+		r.Intervals = append(r.Intervals, &Interval{
+			ElapsedSeconds:  float64(i),
+			DurationSeconds: 1,
+			Success:         100,
+			Failure:         int64(i),
+			Rate:            100,
+			TargetRate:      100,
+			LatencyP50:      1.5,
+			LatencyP99:      3,
+			LatencyMax:      10,
+		})
+	}
+
+	dir := t.TempDir()
+	if err := r.WriteCharts(dir); err != nil {
+		t.Fatalf("failed to write the charts: %v", err)
+	}
+
+	for _, name := range []string{"throughput.svg", "latency.svg", "errors.svg", "latency_histogram.svg"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to read the chart: %v", err)
+		}
+
+		// The chart should be a well-formed XML document.
+		dec := xml.NewDecoder(strings.NewReader(string(data)))
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("the chart '%s' is not well-formed: %v", name, err)
+			}
+		}
+
+		if !strings.Contains(string(data), "<polyline") && !strings.Contains(string(data), "<rect x=") {
+			t.Fatalf("the chart '%s' has no data: %s", name, data)
+		}
+	}
+}
+
+func TestNiceTicks(t *testing.T) {
+	tests := []struct {
+		v        float64
+		expected []float64
+	}{
+		{v: 0, expected: []float64{0, 1}},
+		{v: 9, expected: []float64{0, 2, 4, 6, 8, 10}},
+		{v: 100, expected: []float64{0, 20, 40, 60, 80, 100}},
+		{v: 0.3, expected: []float64{0, 0.1, 0.2, 0.3}},
+	}
+
+	for _, tt := range tests {
+		if actual := niceTicks(tt.v); !reflect.DeepEqual(actual, tt.expected) {
+			t.Fatalf("niceTicks('%f'): '%v', expected: '%v'", tt.v, actual, tt.expected)
+		}
+	}
+}
+
+func TestNewBuckets(t *testing.T) {
+	h := collector.NewHistogram()
+	for _, d := range []time.Duration{300 * time.Microsecond, 800 * time.Microsecond, 1500 * time.Microsecond, 7 * time.Millisecond} {
+		h.Record(d)
+	}
+
+	var (
+		bounds []float64
+		total  int64
+	)
+	for _, bucket := range newBuckets(h) {
+		bounds = append(bounds, bucket.UpperBound)
+		total += bucket.Count
+	}
+
+	if expected := []float64{0.5, 1, 2, 5, 10}; !reflect.DeepEqual(bounds, expected) {
+		t.Fatalf("unexpected bounds: '%v', expected: '%v'", bounds, expected)
+	}
+
+	if total != h.Count() {
+		t.Fatalf("unexpected total: '%d', expected: '%d'", total, h.Count())
+	}
+}